@@ -81,7 +81,8 @@ func handleTokenize(c echo.Context) error {
 	}
 
 	return c.JSON(http.StatusOK, map[string]interface{}{
-		"tokens": tokens,
+		"tokens":      tokens,
+		"diagnostics": l.Diagnostics(),
 	})
 }
 
@@ -184,7 +185,7 @@ func handleEval(c echo.Context) error {
 			continue
 		}
 
-		response = append(response, evaluated.Inspect())
+		response = append(response, object.Report(evaluated))
 	}
 
 	return c.JSON(http.StatusOK, response)
@@ -235,7 +236,7 @@ func handleRepl(c echo.Context) error {
 			}
 
 			if !codeBlock.IsExecuted {
-				response = append(response, evaluated.Inspect())
+				response = append(response, object.Report(evaluated))
 			}
 		}
 	}
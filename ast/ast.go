@@ -11,6 +11,7 @@ import (
 type Node interface {
 	TokenLiteral() string
 	String() string
+	Pos() token.Position // position of the token the node was parsed from
 }
 
 // Statement is the interface that all statement nodes in the AST implement.
@@ -40,6 +41,14 @@ func (p *Program) TokenLiteral() string {
 	}
 }
 
+func (p *Program) Pos() token.Position {
+	if len(p.Statements) > 0 {
+		return p.Statements[0].Pos()
+	}
+
+	return token.Position{}
+}
+
 func (p *Program) String() string {
 	var out bytes.Buffer
 
@@ -56,11 +65,16 @@ type Identifier struct {
 }
 
 func (i *Identifier) expressionNode() {}
+func (i *Identifier) patternNode()    {}
 
 func (i *Identifier) TokenLiteral() string {
 	return i.Token.Literal
 }
 
+func (i *Identifier) Pos() token.Position {
+	return i.Token.Start
+}
+
 func (i *Identifier) String() string {
 	return i.Value
 }
@@ -76,6 +90,10 @@ func (es *ExpressionStatement) TokenLiteral() string {
 	return es.Token.Literal
 }
 
+func (es *ExpressionStatement) Pos() token.Position {
+	return es.Token.Start
+}
+
 func (es *ExpressionStatement) String() string {
 	if es.Expression != nil {
 		return es.Expression.String()
@@ -85,9 +103,10 @@ func (es *ExpressionStatement) String() string {
 }
 
 type AtomStatement struct {
-	Token token.Token // the token.ATOM token
-	Name  *Identifier
-	Value Expression
+	Token   token.Token // the token.ATOM token
+	Name    *Identifier
+	Pattern Pattern // set instead of Name when the value is destructured
+	Value   Expression
 }
 
 func (as *AtomStatement) statementNode() {}
@@ -96,12 +115,21 @@ func (as *AtomStatement) TokenLiteral() string {
 	return as.Token.Literal
 }
 
+func (as *AtomStatement) Pos() token.Position {
+	return as.Token.Start
+}
+
 func (as *AtomStatement) String() string {
 	var out bytes.Buffer
 
 	out.WriteString(as.TokenLiteral() + " ")
 
-	out.WriteString(as.Name.String() + " ")
+	if as.Pattern != nil {
+		out.WriteString(as.Pattern.String() + " ")
+	} else {
+		out.WriteString(as.Name.String() + " ")
+	}
+
 	out.WriteString("=" + " ")
 
 	if as.Value != nil {
@@ -114,9 +142,10 @@ func (as *AtomStatement) String() string {
 }
 
 type MoleculeStatement struct {
-	Token token.Token // the token.MOLECULE token
-	Name  *Identifier
-	Value Expression
+	Token   token.Token // the token.MOLECULE token
+	Name    *Identifier
+	Pattern Pattern // set instead of Name when the value is destructured
+	Value   Expression
 }
 
 func (ms *MoleculeStatement) statementNode() {}
@@ -125,12 +154,21 @@ func (ms *MoleculeStatement) TokenLiteral() string {
 	return ms.Token.Literal
 }
 
+func (ms *MoleculeStatement) Pos() token.Position {
+	return ms.Token.Start
+}
+
 func (ms *MoleculeStatement) String() string {
 	var out bytes.Buffer
 
 	out.WriteString(ms.TokenLiteral() + " ")
 
-	out.WriteString(ms.Name.String() + " ")
+	if ms.Pattern != nil {
+		out.WriteString(ms.Pattern.String() + " ")
+	} else {
+		out.WriteString(ms.Name.String() + " ")
+	}
+
 	out.WriteString("=" + " ")
 
 	if ms.Value != nil {
@@ -153,6 +191,10 @@ func (ps *ProduceStatementStruct) TokenLiteral() string {
 	return ps.Token.Literal
 }
 
+func (ps *ProduceStatementStruct) Pos() token.Position {
+	return ps.Token.Start
+}
+
 func (ps *ProduceStatementStruct) String() string {
 	var out bytes.Buffer
 
@@ -167,6 +209,68 @@ func (ps *ProduceStatementStruct) String() string {
 	return out.String()
 }
 
+// ThrowStatement raises Value as an error.
+type ThrowStatement struct {
+	Token token.Token // the 'throw' token
+	Value Expression
+}
+
+func (ts *ThrowStatement) statementNode() {}
+
+func (ts *ThrowStatement) TokenLiteral() string {
+	return ts.Token.Literal
+}
+
+func (ts *ThrowStatement) Pos() token.Position {
+	return ts.Token.Start
+}
+
+func (ts *ThrowStatement) String() string {
+	return ts.TokenLiteral() + " " + ts.Value.String() + ";"
+}
+
+// TryStatement is try { } catch (e) { } finally { }. Either Catch or Finally may be nil,
+// and Param is nil for a catch that does not name the error.
+type TryStatement struct {
+	Token   token.Token // the 'try' token
+	Block   *BlockStatement
+	Param   *Identifier
+	Catch   *BlockStatement
+	Finally *BlockStatement
+}
+
+func (ts *TryStatement) statementNode() {}
+
+func (ts *TryStatement) TokenLiteral() string {
+	return ts.Token.Literal
+}
+
+func (ts *TryStatement) Pos() token.Position {
+	return ts.Token.Start
+}
+
+func (ts *TryStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("try " + ts.Block.String())
+
+	if ts.Catch != nil {
+		out.WriteString(" catch ")
+
+		if ts.Param != nil {
+			out.WriteString("(" + ts.Param.String() + ") ")
+		}
+
+		out.WriteString(ts.Catch.String())
+	}
+
+	if ts.Finally != nil {
+		out.WriteString(" finally " + ts.Finally.String())
+	}
+
+	return out.String()
+}
+
 type IntegerLiteral struct {
 	Token token.Token
 	Value int64
@@ -178,10 +282,33 @@ func (il *IntegerLiteral) TokenLiteral() string {
 	return il.Token.Literal
 }
 
+func (il *IntegerLiteral) Pos() token.Position {
+	return il.Token.Start
+}
+
 func (il *IntegerLiteral) String() string {
 	return il.Token.Literal
 }
 
+type FloatLiteral struct {
+	Token token.Token
+	Value float64
+}
+
+func (fl *FloatLiteral) expressionNode() {}
+
+func (fl *FloatLiteral) TokenLiteral() string {
+	return fl.Token.Literal
+}
+
+func (fl *FloatLiteral) Pos() token.Position {
+	return fl.Token.Start
+}
+
+func (fl *FloatLiteral) String() string {
+	return fl.Token.Literal
+}
+
 type StringLiteral struct {
 	Token token.Token
 	Value string
@@ -193,10 +320,52 @@ func (sl *StringLiteral) TokenLiteral() string {
 	return sl.Token.Literal
 }
 
+func (sl *StringLiteral) Pos() token.Position {
+	return sl.Token.Start
+}
+
 func (sl *StringLiteral) String() string {
 	return sl.Token.Literal
 }
 
+// InterpolatedString is a string with embedded expressions, "mass of ${name} is ${m}".
+// Parts holds the text between the expressions as *StringLiteral, in source order with the expressions.
+type InterpolatedString struct {
+	Token token.Token // the token.STRING_HEAD token
+	Parts []Expression
+}
+
+func (is *InterpolatedString) expressionNode() {}
+
+func (is *InterpolatedString) TokenLiteral() string {
+	return is.Token.Literal
+}
+
+func (is *InterpolatedString) Pos() token.Position {
+	return is.Token.Start
+}
+
+func (is *InterpolatedString) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("\"")
+
+	for _, part := range is.Parts {
+		if text, ok := part.(*StringLiteral); ok {
+			out.WriteString(text.Value)
+			continue
+		}
+
+		out.WriteString("${")
+		out.WriteString(part.String())
+		out.WriteString("}")
+	}
+
+	out.WriteString("\"")
+
+	return out.String()
+}
+
 type PrefixExpression struct {
 	Token    token.Token
 	Operator string
@@ -209,6 +378,10 @@ func (pe *PrefixExpression) TokenLiteral() string {
 	return pe.Token.Literal
 }
 
+func (pe *PrefixExpression) Pos() token.Position {
+	return pe.Token.Start
+}
+
 func (pe *PrefixExpression) String() string {
 	var out bytes.Buffer
 
@@ -233,6 +406,10 @@ func (inf *InfixExpression) TokenLiteral() string {
 	return inf.Token.Literal
 }
 
+func (inf *InfixExpression) Pos() token.Position {
+	return inf.Token.Start
+}
+
 func (inf *InfixExpression) String() string {
 	var out bytes.Buffer
 
@@ -245,6 +422,88 @@ func (inf *InfixExpression) String() string {
 	return out.String()
 }
 
+// AssignExpression stores Value in the existing binding named by Target,
+// or in the array element or hash entry when Target is an IndexExpression.
+// Operator is "=" or a compound form such as "+=".
+type AssignExpression struct {
+	Token    token.Token // the assignment operator token
+	Target   Expression
+	Operator string
+	Value    Expression
+}
+
+func (ae *AssignExpression) expressionNode() {}
+
+func (ae *AssignExpression) TokenLiteral() string {
+	return ae.Token.Literal
+}
+
+func (ae *AssignExpression) Pos() token.Position {
+	return ae.Token.Start
+}
+
+func (ae *AssignExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("(")
+	out.WriteString(ae.Target.String())
+	out.WriteString(" " + ae.Operator + " ")
+	out.WriteString(ae.Value.String())
+	out.WriteString(")")
+
+	return out.String()
+}
+
+// ConditionalExpression is cond ? consequence : alternative.
+type ConditionalExpression struct {
+	Token       token.Token // the '?' token
+	Condition   Expression
+	Consequence Expression
+	Alternative Expression
+}
+
+func (ce *ConditionalExpression) expressionNode() {}
+
+func (ce *ConditionalExpression) TokenLiteral() string {
+	return ce.Token.Literal
+}
+
+func (ce *ConditionalExpression) Pos() token.Position {
+	return ce.Token.Start
+}
+
+func (ce *ConditionalExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("(")
+	out.WriteString(ce.Condition.String())
+	out.WriteString(" ? ")
+	out.WriteString(ce.Consequence.String())
+	out.WriteString(" : ")
+	out.WriteString(ce.Alternative.String())
+	out.WriteString(")")
+
+	return out.String()
+}
+
+type NullLiteral struct {
+	Token token.Token
+}
+
+func (nl *NullLiteral) expressionNode() {}
+
+func (nl *NullLiteral) TokenLiteral() string {
+	return nl.Token.Literal
+}
+
+func (nl *NullLiteral) Pos() token.Position {
+	return nl.Token.Start
+}
+
+func (nl *NullLiteral) String() string {
+	return nl.Token.Literal
+}
+
 type Boolean struct {
 	Token token.Token
 	Value bool
@@ -256,6 +515,10 @@ func (b *Boolean) TokenLiteral() string {
 	return b.Token.Literal
 }
 
+func (b *Boolean) Pos() token.Position {
+	return b.Token.Start
+}
+
 func (b *Boolean) String() string {
 	return b.Token.Literal
 }
@@ -268,6 +531,7 @@ type BlockStatement struct {
 func (bs *BlockStatement) statementNode() {}
 
 func (bs *BlockStatement) TokenLiteral() string { return bs.Token.Literal }
+func (bs *BlockStatement) Pos() token.Position  { return bs.Token.Start }
 
 func (bs *BlockStatement) String() string {
 	var out bytes.Buffer
@@ -290,6 +554,10 @@ func (ie *IfExpression) TokenLiteral() string {
 	return ie.Token.Literal
 }
 
+func (ie *IfExpression) Pos() token.Position {
+	return ie.Token.Start
+}
+
 func (ie *IfExpression) String() string {
 	var out bytes.Buffer
 
@@ -306,6 +574,389 @@ func (ie *IfExpression) String() string {
 	return out.String()
 }
 
+// WhileStatement repeats Body for as long as Condition is truthy.
+type WhileStatement struct {
+	Token     token.Token // the token.WHILE token
+	Label     *Identifier // nil unless the loop is written as `label: while (...)`
+	Condition Expression
+	Body      *BlockStatement
+}
+
+func (ws *WhileStatement) statementNode() {}
+
+func (ws *WhileStatement) TokenLiteral() string {
+	return ws.Token.Literal
+}
+
+func (ws *WhileStatement) Pos() token.Position {
+	return ws.Token.Start
+}
+
+func (ws *WhileStatement) String() string {
+	var out bytes.Buffer
+
+	if ws.Label != nil {
+		out.WriteString(ws.Label.String() + ": ")
+	}
+
+	out.WriteString("while ")
+	out.WriteString(ws.Condition.String())
+	out.WriteString(" ")
+	out.WriteString(ws.Body.String())
+
+	return out.String()
+}
+
+// ForStatement runs Body once for every item of Iterable.
+// With one variable, Value gets the elements of an array, the characters of a string,
+// the numbers of a range or the keys of a hash. With two, Key gets the index
+// (or the hash key) and Value the element (or the hash value).
+type ForStatement struct {
+	Token    token.Token // the token.FOR token
+	Label    *Identifier // nil unless the loop is written as `label: for (...)`
+	Key      *Identifier // nil when only one variable is given
+	Value    *Identifier
+	Iterable Expression
+	Body     *BlockStatement
+}
+
+func (fs *ForStatement) statementNode() {}
+
+func (fs *ForStatement) TokenLiteral() string {
+	return fs.Token.Literal
+}
+
+func (fs *ForStatement) Pos() token.Position {
+	return fs.Token.Start
+}
+
+func (fs *ForStatement) String() string {
+	var out bytes.Buffer
+
+	if fs.Label != nil {
+		out.WriteString(fs.Label.String() + ": ")
+	}
+
+	out.WriteString("for (")
+
+	if fs.Key != nil {
+		out.WriteString(fs.Key.String() + ", ")
+	}
+
+	out.WriteString(fs.Value.String())
+	out.WriteString(" in ")
+	out.WriteString(fs.Iterable.String())
+	out.WriteString(") ")
+	out.WriteString(fs.Body.String())
+
+	return out.String()
+}
+
+// BreakStatement leaves the innermost loop, or the loop named by Label.
+type BreakStatement struct {
+	Token token.Token // the token.BREAK token
+	Label *Identifier
+}
+
+func (bs *BreakStatement) statementNode() {}
+
+func (bs *BreakStatement) TokenLiteral() string {
+	return bs.Token.Literal
+}
+
+func (bs *BreakStatement) Pos() token.Position {
+	return bs.Token.Start
+}
+
+func (bs *BreakStatement) String() string {
+	if bs.Label != nil {
+		return bs.TokenLiteral() + " " + bs.Label.String() + ";"
+	}
+
+	return bs.TokenLiteral() + ";"
+}
+
+// ContinueStatement skips to the next pass of the innermost loop, or of the loop named by Label.
+type ContinueStatement struct {
+	Token token.Token // the token.CONTINUE token
+	Label *Identifier
+}
+
+func (cs *ContinueStatement) statementNode() {}
+
+func (cs *ContinueStatement) TokenLiteral() string {
+	return cs.Token.Literal
+}
+
+func (cs *ContinueStatement) Pos() token.Position {
+	return cs.Token.Start
+}
+
+func (cs *ContinueStatement) String() string {
+	if cs.Label != nil {
+		return cs.TokenLiteral() + " " + cs.Label.String() + ";"
+	}
+
+	return cs.TokenLiteral() + ";"
+}
+
+// Parameter is one parameter of a reaction: a plain name, a name with a Default
+// used when the caller leaves it out, or a Rest parameter collecting the remaining arguments.
+// A destructured parameter has a Pattern instead of a Name.
+type Parameter struct {
+	Token   token.Token // the name token, '[' or '{' for a pattern, or '...' for a rest parameter
+	Name    *Identifier
+	Pattern Pattern
+	Default Expression
+	Rest    bool
+}
+
+func (pa *Parameter) TokenLiteral() string {
+	return pa.Token.Literal
+}
+
+func (pa *Parameter) Pos() token.Position {
+	return pa.Token.Start
+}
+
+func (pa *Parameter) String() string {
+	if pa.Rest {
+		return "..." + pa.Name.String()
+	}
+
+	target := Node(pa.Name)
+	if pa.Pattern != nil {
+		target = pa.Pattern
+	}
+
+	if pa.Default != nil {
+		return target.String() + " = " + pa.Default.String()
+	}
+
+	return target.String()
+}
+
+// Pattern is the target of a binding: an Identifier, or an ArrayPattern or HashPattern
+// that takes a value apart and binds its pieces.
+type Pattern interface {
+	Node
+	patternNode()
+}
+
+// PatternElement is one target inside a pattern, with the Default used when the value is missing.
+type PatternElement struct {
+	Target  Pattern
+	Default Expression
+}
+
+func (pe *PatternElement) String() string {
+	if pe.Default != nil {
+		return pe.Target.String() + " = " + pe.Default.String()
+	}
+
+	return pe.Target.String()
+}
+
+// ArrayPattern binds elements by position: [a, b = 2, ...rest].
+type ArrayPattern struct {
+	Token    token.Token // the '[' token
+	Elements []*PatternElement
+	Rest     *Identifier // gets an array of the remaining elements
+}
+
+func (ap *ArrayPattern) patternNode() {}
+
+func (ap *ArrayPattern) TokenLiteral() string {
+	return ap.Token.Literal
+}
+
+func (ap *ArrayPattern) Pos() token.Position {
+	return ap.Token.Start
+}
+
+func (ap *ArrayPattern) String() string {
+	elements := []string{}
+
+	for _, el := range ap.Elements {
+		elements = append(elements, el.String())
+	}
+
+	if ap.Rest != nil {
+		elements = append(elements, "..."+ap.Rest.String())
+	}
+
+	return "[" + strings.Join(elements, ", ") + "]"
+}
+
+// HashPatternEntry binds the value stored under Key, to Target or to a variable named Key.
+type HashPatternEntry struct {
+	Key *Identifier
+	*PatternElement
+}
+
+func (he *HashPatternEntry) String() string {
+	if ident, ok := he.Target.(*Identifier); ok && ident.Value == he.Key.Value {
+		return he.PatternElement.String()
+	}
+
+	return he.Key.String() + ": " + he.PatternElement.String()
+}
+
+// HashPattern binds hash entries by key: {name, symbol: sym, ...rest}.
+type HashPattern struct {
+	Token   token.Token // the '{' token
+	Entries []*HashPatternEntry
+	Rest    *Identifier // gets a hash of the entries not listed
+}
+
+func (hp *HashPattern) patternNode() {}
+
+func (hp *HashPattern) TokenLiteral() string {
+	return hp.Token.Literal
+}
+
+func (hp *HashPattern) Pos() token.Position {
+	return hp.Token.Start
+}
+
+func (hp *HashPattern) String() string {
+	entries := []string{}
+
+	for _, entry := range hp.Entries {
+		entries = append(entries, entry.String())
+	}
+
+	if hp.Rest != nil {
+		entries = append(entries, "..."+hp.Rest.String())
+	}
+
+	return "{" + strings.Join(entries, ", ") + "}"
+}
+
+// LiteralPattern matches values equal to a literal: 1, -2.5, "metal", true or null.
+// It is only allowed in a match arm.
+type LiteralPattern struct {
+	Value Expression
+}
+
+func (lp *LiteralPattern) patternNode() {}
+
+func (lp *LiteralPattern) TokenLiteral() string {
+	return lp.Value.TokenLiteral()
+}
+
+func (lp *LiteralPattern) Pos() token.Position {
+	return lp.Value.Pos()
+}
+
+func (lp *LiteralPattern) String() string {
+	return lp.Value.String()
+}
+
+// WildcardPattern is the _ of a match arm, which matches anything and binds nothing.
+type WildcardPattern struct {
+	Token token.Token // the '_' token
+}
+
+func (wp *WildcardPattern) patternNode() {}
+
+func (wp *WildcardPattern) TokenLiteral() string {
+	return wp.Token.Literal
+}
+
+func (wp *WildcardPattern) Pos() token.Position {
+	return wp.Token.Start
+}
+
+func (wp *WildcardPattern) String() string {
+	return "_"
+}
+
+// MatchArm is one pattern if guard => body of a match expression. Guard is nil when there is none.
+type MatchArm struct {
+	Pattern Pattern
+	Guard   Expression
+	Body    *BlockStatement
+}
+
+func (ma *MatchArm) String() string {
+	out := ma.Pattern.String()
+
+	if ma.Guard != nil {
+		out += " if " + ma.Guard.String()
+	}
+
+	return out + " => " + ma.Body.String()
+}
+
+// MatchExpression evaluates to the body of the first arm whose pattern matches Subject.
+type MatchExpression struct {
+	Token   token.Token // the 'match' token
+	Subject Expression
+	Arms    []*MatchArm
+}
+
+func (me *MatchExpression) expressionNode() {}
+
+func (me *MatchExpression) TokenLiteral() string {
+	return me.Token.Literal
+}
+
+func (me *MatchExpression) Pos() token.Position {
+	return me.Token.Start
+}
+
+func (me *MatchExpression) String() string {
+	arms := []string{}
+
+	for _, arm := range me.Arms {
+		arms = append(arms, arm.String())
+	}
+
+	return "match " + me.Subject.String() + " { " + strings.Join(arms, ", ") + " }"
+}
+
+// NamedArgument is a name: value argument of a call.
+type NamedArgument struct {
+	Token token.Token // the name token
+	Name  *Identifier
+	Value Expression
+}
+
+func (na *NamedArgument) expressionNode() {}
+
+func (na *NamedArgument) TokenLiteral() string {
+	return na.Token.Literal
+}
+
+func (na *NamedArgument) Pos() token.Position {
+	return na.Token.Start
+}
+
+func (na *NamedArgument) String() string {
+	return na.Name.String() + ": " + na.Value.String()
+}
+
+// SpreadElement is ...value inside an array literal, a hash literal or a call's arguments.
+type SpreadElement struct {
+	Token token.Token // the '...' token
+	Value Expression
+}
+
+func (se *SpreadElement) expressionNode() {}
+
+func (se *SpreadElement) TokenLiteral() string {
+	return se.Token.Literal
+}
+
+func (se *SpreadElement) Pos() token.Position {
+	return se.Token.Start
+}
+
+func (se *SpreadElement) String() string {
+	return "..." + se.Value.String()
+}
+
 type ReactionStatement struct {
 	Name *Identifier
 	*ReactionLiteral
@@ -315,6 +966,9 @@ func (rs *ReactionStatement) statementNode() {}
 func (rs *ReactionStatement) TokenLiteral() string {
 	return rs.Token.Literal
 }
+func (rs *ReactionStatement) Pos() token.Position {
+	return rs.Token.Start
+}
 func (rs *ReactionStatement) String() string {
 	var out bytes.Buffer
 
@@ -335,12 +989,13 @@ func (rs *ReactionStatement) String() string {
 
 type ReactionLiteral struct {
 	Token      token.Token // The 'reaction' token
-	Parameters []*Identifier
+	Parameters []*Parameter
 	Body       *BlockStatement
 }
 
 func (fl *ReactionLiteral) expressionNode()      {}
 func (fl *ReactionLiteral) TokenLiteral() string { return fl.Token.Literal }
+func (fl *ReactionLiteral) Pos() token.Position  { return fl.Token.Start }
 func (fl *ReactionLiteral) String() string {
 	var out bytes.Buffer
 
@@ -359,13 +1014,15 @@ func (fl *ReactionLiteral) String() string {
 }
 
 type CallExpression struct {
-	Token     token.Token // The '(' token
+	Token     token.Token // The '(' token, '?.' for f?.(x), or '|>' for x |> f
 	Function  Expression  // Identifier or ReactionLiteral
 	Arguments []Expression
+	Optional  bool // f?.(x): the rest of the chain is skipped when Function is null
 }
 
 func (ce *CallExpression) expressionNode()      {}
 func (ce *CallExpression) TokenLiteral() string { return ce.Token.Literal }
+func (ce *CallExpression) Pos() token.Position  { return ce.Token.Start }
 func (ce *CallExpression) String() string {
 	var out bytes.Buffer
 
@@ -375,6 +1032,11 @@ func (ce *CallExpression) String() string {
 	}
 
 	out.WriteString(ce.Function.String())
+
+	if ce.Optional {
+		out.WriteString("?.")
+	}
+
 	out.WriteString("(")
 	out.WriteString(strings.Join(args, ", "))
 	out.WriteString(")")
@@ -389,6 +1051,7 @@ type ArrayLiteral struct {
 
 func (al *ArrayLiteral) expressionNode()      {}
 func (al *ArrayLiteral) TokenLiteral() string { return al.Token.Literal }
+func (al *ArrayLiteral) Pos() token.Position  { return al.Token.Start }
 
 func (al *ArrayLiteral) String() string {
 	var out bytes.Buffer
@@ -405,21 +1068,39 @@ func (al *ArrayLiteral) String() string {
 	return out.String()
 }
 
+// IndexExpression is left[index], or left.name, which is parsed with a StringLiteral index.
 type IndexExpression struct {
-	Token token.Token // The '[' token
-	Left  Expression
-	Index Expression
+	Token    token.Token // The '[' or '.' token, or '?.' for a?.[i] and a?.name
+	Left     Expression
+	Index    Expression
+	Optional bool // the rest of the chain is skipped when Left is null
+	Field    bool // written as left.name rather than left[index]
 }
 
 func (ie *IndexExpression) expressionNode() {}
 
 func (ie *IndexExpression) TokenLiteral() string { return ie.Token.Literal }
+func (ie *IndexExpression) Pos() token.Position  { return ie.Token.Start }
 
 func (ie *IndexExpression) String() string {
 	var out bytes.Buffer
 
 	out.WriteString("(")
 	out.WriteString(ie.Left.String())
+
+	if ie.Optional {
+		out.WriteString("?.")
+	}
+
+	if ie.Field {
+		if !ie.Optional {
+			out.WriteString(".")
+		}
+
+		out.WriteString(ie.Index.String() + ")")
+		return out.String()
+	}
+
 	out.WriteString("[")
 	out.WriteString(ie.Index.String())
 	out.WriteString("])")
@@ -430,18 +1111,25 @@ func (ie *IndexExpression) String() string {
 type HashLiteral struct {
 	Token token.Token // The '{' token
 	Pairs map[Expression]Expression
+	Order []Expression // the keys of Pairs in source order, with any *SpreadElement between them
 }
 
 func (hl *HashLiteral) expressionNode()      {}
 func (hl *HashLiteral) TokenLiteral() string { return hl.Token.Literal }
+func (hl *HashLiteral) Pos() token.Position  { return hl.Token.Start }
 
 func (hl *HashLiteral) String() string {
 	var out bytes.Buffer
 
 	pairs := []string{}
 
-	for key, value := range hl.Pairs {
-		pairs = append(pairs, key.String()+":"+value.String())
+	for _, key := range hl.Order {
+		if spread, ok := key.(*SpreadElement); ok {
+			pairs = append(pairs, spread.String())
+			continue
+		}
+
+		pairs = append(pairs, key.String()+":"+hl.Pairs[key].String())
 	}
 
 	out.WriteString("{")
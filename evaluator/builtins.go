@@ -3,13 +3,18 @@ package evaluator
 import (
 	"atom_script/object"
 	"bytes"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"unicode/utf8"
 )
 
 var builtins = map[string]*object.Builtin{
 	"len": {
 		Fn: func(args ...object.Object) object.Object {
 			if len(args) != 1 {
-				return newError("wrong number of arguments. got=%d, want=1",
+				return newError(object.ARGUMENT_ERROR, "wrong number of arguments. got=%d, want=1",
 					len(args))
 			}
 
@@ -20,13 +25,89 @@ var builtins = map[string]*object.Builtin{
 			case *object.String:
 				return &object.Integer{Value: int64(len(arg.Value))}
 
+			case *object.Range:
+				return &object.Integer{Value: arg.Len()}
+
 			default:
-				return newError("argument to `len` not supported, got %s",
+				return newError(object.TYPE_ERROR, "argument to `len` not supported, got %s",
 					args[0].Type())
 			}
 		},
 	},
 
+	// runeLen is the rune-aware counterpart of len: it counts characters instead of bytes.
+	"runeLen": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError(object.ARGUMENT_ERROR, "wrong number of arguments. got=%d, want=1",
+					len(args))
+			}
+
+			if args[0].Type() != object.STRING_OBJ {
+				return newError(object.TYPE_ERROR, "argument to `runeLen` must be STRING, got %s",
+					args[0].Type())
+			}
+
+			str := args[0].(*object.String)
+
+			return &object.Integer{Value: int64(utf8.RuneCountInString(str.Value))}
+		},
+	},
+
+	// runes splits a string into an array holding one string per character.
+	"runes": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError(object.ARGUMENT_ERROR, "wrong number of arguments. got=%d, want=1",
+					len(args))
+			}
+
+			if args[0].Type() != object.STRING_OBJ {
+				return newError(object.TYPE_ERROR, "argument to `runes` must be STRING, got %s",
+					args[0].Type())
+			}
+
+			str := args[0].(*object.String)
+
+			elements := make([]object.Object, 0, len(str.Value))
+
+			for _, r := range str.Value {
+				elements = append(elements, &object.String{Value: string(r)})
+			}
+
+			return &object.Array{Elements: elements}
+		},
+	},
+
+	// runeAt returns the character at the given rune index, or null when the index is out of range.
+	"runeAt": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError(object.ARGUMENT_ERROR, "wrong number of arguments. got=%d, want=2",
+					len(args))
+			}
+
+			if args[0].Type() != object.STRING_OBJ {
+				return newError(object.TYPE_ERROR, "first argument to `runeAt` must be STRING, got %s",
+					args[0].Type())
+			}
+
+			if args[1].Type() != object.INTEGER_OBJ {
+				return newError(object.TYPE_ERROR, "second argument to `runeAt` must be INTEGER, got %s",
+					args[1].Type())
+			}
+
+			runes := []rune(args[0].(*object.String).Value)
+			idx := args[1].(*object.Integer).Value
+
+			if idx < 0 || idx >= int64(len(runes)) {
+				return NULL
+			}
+
+			return &object.String{Value: string(runes[idx])}
+		},
+	},
+
 	"puts": {
 		Fn: func(args ...object.Object) object.Object {
 			out := bytes.Buffer{}
@@ -42,15 +123,161 @@ var builtins = map[string]*object.Builtin{
 		},
 	},
 
+	// int converts a FLOAT (truncating towards zero) or a STRING to an integer.
+	// Values outside the int64 range become a BIGINT.
+	"int": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError(object.ARGUMENT_ERROR, "wrong number of arguments. got=%d, want=1",
+					len(args))
+			}
+
+			switch arg := args[0].(type) {
+			case *object.Integer, *object.BigInt:
+				return arg
+
+			case *object.Float:
+				if math.IsNaN(arg.Value) || math.IsInf(arg.Value, 0) {
+					return newError(object.VALUE_ERROR, "cannot convert %s to INTEGER", arg.Inspect())
+				}
+
+				value, _ := big.NewFloat(arg.Value).Int(nil)
+
+				return newInteger(value)
+
+			case *object.String:
+				value, ok := new(big.Int).SetString(strings.TrimSpace(arg.Value), 0)
+				if !ok {
+					return newError(object.VALUE_ERROR, "cannot convert %q to INTEGER", arg.Value)
+				}
+
+				return newInteger(value)
+
+			default:
+				return newError(object.TYPE_ERROR, "argument to `int` not supported, got %s",
+					args[0].Type())
+			}
+		},
+	},
+
+	// float converts an integer or a STRING to a FLOAT.
+	"float": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError(object.ARGUMENT_ERROR, "wrong number of arguments. got=%d, want=1",
+					len(args))
+			}
+
+			switch arg := args[0].(type) {
+			case *object.Float:
+				return arg
+
+			case *object.Integer, *object.BigInt:
+				return &object.Float{Value: toFloat(arg)}
+
+			case *object.String:
+				value, err := strconv.ParseFloat(strings.TrimSpace(arg.Value), 64)
+				if err != nil {
+					return newError(object.VALUE_ERROR, "cannot convert %q to FLOAT", arg.Value)
+				}
+
+				return &object.Float{Value: value}
+
+			default:
+				return newError(object.TYPE_ERROR, "argument to `float` not supported, got %s",
+					args[0].Type())
+			}
+		},
+	},
+
+	// range yields the integers from start up to (not including) end: range(end), range(start, end)
+	// or range(start, end, step). The numbers are produced as a loop asks for them.
+	"range": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) < 1 || len(args) > 3 {
+				return newError(object.ARGUMENT_ERROR, "wrong number of arguments. got=%d, want=1..3",
+					len(args))
+			}
+
+			bounds := make([]int64, len(args))
+
+			for i, arg := range args {
+				integer, ok := arg.(*object.Integer)
+				if !ok {
+					return newError(object.TYPE_ERROR, "arguments to `range` must be INTEGER, got %s",
+						arg.Type())
+				}
+
+				bounds[i] = integer.Value
+			}
+
+			r := &object.Range{Step: 1}
+
+			switch len(bounds) {
+			case 1:
+				r.End = bounds[0]
+			case 2:
+				r.Start, r.End = bounds[0], bounds[1]
+			case 3:
+				r.Start, r.End, r.Step = bounds[0], bounds[1], bounds[2]
+			}
+
+			if r.Step == 0 {
+				return newError(object.VALUE_ERROR, "step of `range` must not be 0")
+			}
+
+			if r.Count() > math.MaxInt64 {
+				return newError(object.VALUE_ERROR, "%s has too many elements", r.Inspect())
+			}
+
+			return r
+		},
+	},
+
+	// error makes an exception to throw: error(message), error(message, kind) or
+	// error(message, kind, payload). The kind defaults to "Error".
+	"error": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) < 1 || len(args) > 3 {
+				return newError(object.ARGUMENT_ERROR, "wrong number of arguments. got=%d, want=1..3",
+					len(args))
+			}
+
+			message, ok := args[0].(*object.String)
+			if !ok {
+				return newError(object.TYPE_ERROR, "first argument to `error` must be STRING, got %s",
+					args[0].Type())
+			}
+
+			err := &object.Error{Message: message.Value, Kind: object.GENERIC_ERROR}
+
+			if len(args) > 1 {
+				kind, ok := args[1].(*object.String)
+				if !ok {
+					return newError(object.TYPE_ERROR, "second argument to `error` must be STRING, got %s",
+						args[1].Type())
+				}
+
+				err.Kind = kind.Value
+			}
+
+			if len(args) > 2 {
+				err.Payload = args[2]
+			}
+
+			return &object.Exception{Error: err}
+		},
+	},
+
 	"first": {
 		Fn: func(args ...object.Object) object.Object {
 			if len(args) != 1 {
-				return newError("wrong number of arguments. got=%d, want=1",
+				return newError(object.ARGUMENT_ERROR, "wrong number of arguments. got=%d, want=1",
 					len(args))
 			}
 
 			if args[0].Type() != object.ARRAY_OBJ {
-				return newError("argument to `first` must be ARRAY, got %s",
+				return newError(object.TYPE_ERROR, "argument to `first` must be ARRAY, got %s",
 					args[0].Type())
 			}
 
@@ -67,12 +294,12 @@ var builtins = map[string]*object.Builtin{
 	"last": {
 		Fn: func(args ...object.Object) object.Object {
 			if len(args) != 1 {
-				return newError("wrong number of arguments. got=%d, want=1",
+				return newError(object.ARGUMENT_ERROR, "wrong number of arguments. got=%d, want=1",
 					len(args))
 			}
 
 			if args[0].Type() != object.ARRAY_OBJ {
-				return newError("argument to `first` must be ARRAY, got %s",
+				return newError(object.TYPE_ERROR, "argument to `first` must be ARRAY, got %s",
 					args[0].Type())
 			}
 
@@ -90,12 +317,12 @@ var builtins = map[string]*object.Builtin{
 	"rest": {
 		Fn: func(args ...object.Object) object.Object {
 			if len(args) != 1 {
-				return newError("wrong number of arguments. got=%d, want=1",
+				return newError(object.ARGUMENT_ERROR, "wrong number of arguments. got=%d, want=1",
 					len(args))
 			}
 
 			if args[0].Type() != object.ARRAY_OBJ {
-				return newError("argument to `rest` must be ARRAY, got %s",
+				return newError(object.TYPE_ERROR, "argument to `rest` must be ARRAY, got %s",
 					args[0].Type())
 			}
 
@@ -116,12 +343,12 @@ var builtins = map[string]*object.Builtin{
 	"push": {
 		Fn: func(args ...object.Object) object.Object {
 			if len(args) != 2 {
-				return newError("wrong number of arguments. got=%d, want=2",
+				return newError(object.ARGUMENT_ERROR, "wrong number of arguments. got=%d, want=2",
 					len(args))
 			}
 
 			if args[0].Type() != object.ARRAY_OBJ {
-				return newError("argument to `push` must be ARRAY, got %s",
+				return newError(object.TYPE_ERROR, "argument to `push` must be ARRAY, got %s",
 					args[0].Type())
 			}
 
@@ -3,7 +3,13 @@ package evaluator
 import (
 	"atom_script/ast"
 	"atom_script/object"
+	"atom_script/token"
+	"bytes"
 	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"strings"
 )
 
 var (
@@ -12,6 +18,10 @@ var (
 	FALSE = &object.Boolean{Value: false}
 )
 
+// maxSpreadLength is the most elements a range can be spread into. Arrays are already built,
+// so they have no limit.
+const maxSpreadLength = 1 << 24
+
 func isError(obj object.Object) bool {
 	if obj != nil {
 		return obj.Type() == object.ERROR_OBJ
@@ -19,15 +29,37 @@ func isError(obj object.Object) bool {
 	return false
 }
 
+// interrupts reports whether obj is an error, a produce, a break or a continue. These stop the
+// expression being evaluated and are passed up unchanged to the loop, reaction or program handling them.
+func interrupts(obj object.Object) bool {
+	if obj == nil {
+		return false
+	}
+
+	switch obj.Type() {
+	case object.ERROR_OBJ, object.PRODUCE_VALUE_OBJ, object.BREAK_OBJ, object.CONTINUE_OBJ:
+		return true
+	}
+
+	return false
+}
+
 // Eval function
+// Errors coming out of Eval carry the position of the innermost node that produced them.
 func Eval(node ast.Node, env *object.Environment) object.Object {
+	return withPosition(eval(node, env), node)
+}
+
+func eval(node ast.Node, env *object.Environment) object.Object {
 	switch node := node.(type) {
 	case *ast.Program:
 		return evalProgram(node.Statements, env)
 
 	case *ast.ReactionStatement:
-		rl := &ast.ReactionLiteral{Parameters: node.Parameters, Body: node.Body}
-		env.Set(node.Name.Value, Eval(rl, env))
+		reaction := &object.Reaction{Name: node.Name.Value, Parameters: node.Parameters, Body: node.Body, Env: env}
+		if !env.Define(node.Name.Value, reaction, object.ATOM_BINDING) {
+			return newError(object.NAME_ERROR, "cannot redeclare molecule %s", node.Name.Value)
+		}
 
 	case *ast.ExpressionStatement:
 		return Eval(node.Expression, env)
@@ -35,62 +67,136 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 	case *ast.IntegerLiteral:
 		return &object.Integer{Value: node.Value}
 
+	case *ast.FloatLiteral:
+		return &object.Float{Value: node.Value}
+
 	case *ast.StringLiteral:
 		return &object.String{Value: node.Value}
 
+	case *ast.InterpolatedString:
+		return evalInterpolatedString(node, env)
+
 	case *ast.Boolean:
 		return nativeBoolToBooleanObject(node.Value)
 
+	case *ast.NullLiteral:
+		return NULL
+
 	case *ast.PrefixExpression:
 		right := Eval(node.Right, env)
-		if isError(right) {
+		if interrupts(right) {
 			return right
 		}
 
 		return evalPrefixExpression(node.Operator, right)
 
 	case *ast.InfixExpression:
+		if node.Operator == "&&" || node.Operator == "||" {
+			return evalLogicalExpression(node, env)
+		}
+
+		if node.Operator == "??" {
+			return evalNullishExpression(node, env)
+		}
+
 		left := Eval(node.Left, env)
-		if isError(left) {
+		if interrupts(left) {
 			return left
 		}
 
 		right := Eval(node.Right, env)
-		if isError(right) {
+		if interrupts(right) {
 			return right
 		}
 
 		return evalInfixExpression(node.Operator, left, right)
 
+	case *ast.AssignExpression:
+		return evalAssignExpression(node, env)
+
 	case *ast.BlockStatement:
 		return evalBlockStatement(node, env)
 
 	case *ast.IfExpression:
 		return evalIfExpression(node, env)
 
+	case *ast.MatchExpression:
+		return evalMatchExpression(node, env)
+
+	case *ast.ConditionalExpression:
+		condition := Eval(node.Condition, env)
+		if interrupts(condition) {
+			return condition
+		}
+
+		if isTruthy(condition) {
+			return Eval(node.Consequence, env)
+		}
+
+		return Eval(node.Alternative, env)
+
+	case *ast.WhileStatement:
+		return evalWhileStatement(node, env)
+
+	case *ast.ForStatement:
+		return evalForStatement(node, env)
+
+	case *ast.BreakStatement:
+		return &object.Break{Label: labelOf(node.Label)}
+
+	case *ast.ContinueStatement:
+		return &object.Continue{Label: labelOf(node.Label)}
+
 	case *ast.ProduceStatementStruct:
 		value := Eval(node.ReturnValue, env)
-		if isError(value) {
+		if interrupts(value) {
 			return value
 		}
 
 		return &object.ProduceValue{Value: value}
 
+	case *ast.ThrowStatement:
+		value := Eval(node.Value, env)
+		if interrupts(value) {
+			return value
+		}
+
+		return throwValue(value)
+
+	case *ast.TryStatement:
+		return evalTryStatement(node, env)
+
 	case *ast.AtomStatement:
 		val := Eval(node.Value, env)
-		if isError(val) {
+		if interrupts(val) {
 			return val
 		}
 
-		env.Set(node.Name.Value, val)
+		if node.Pattern != nil {
+			return bindPattern(node.Pattern, val, env, object.ATOM_BINDING)
+		}
+
+		nameReaction(val, node.Value, node.Name.Value)
+
+		if !env.Define(node.Name.Value, val, object.ATOM_BINDING) {
+			return newError(object.NAME_ERROR, "cannot redeclare molecule %s", node.Name.Value)
+		}
 
 	case *ast.MoleculeStatement:
 		val := Eval(node.Value, env)
-		if isError(val) {
+		if interrupts(val) {
 			return val
 		}
 
-		env.Set(node.Name.Value, val)
+		if node.Pattern != nil {
+			return bindPattern(node.Pattern, val, env, object.MOLECULE_BINDING)
+		}
+
+		nameReaction(val, node.Value, node.Name.Value)
+
+		if !env.Define(node.Name.Value, val, object.MOLECULE_BINDING) {
+			return newError(object.NAME_ERROR, "cannot redeclare molecule %s", node.Name.Value)
+		}
 
 	case *ast.Identifier:
 		return evalIdentifier(node, env)
@@ -101,39 +207,21 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		return &object.Reaction{Parameters: params, Body: body, Env: env}
 
 	case *ast.CallExpression:
-		function := Eval(node.Function, env)
-		if isError(function) {
-			return function
-		}
-
-		args := evalExpressions(node.Arguments, env)
-		if len(args) == 1 && isError(args[0]) {
-			return args[0]
-		}
-
-		return applyFunction(function, args)
+		result, _ := evalChain(node, env)
+		return result
 
 	case *ast.ArrayLiteral:
 		elements := evalExpressions(node.Elements, env)
 
-		if len(elements) == 1 && isError(elements[0]) {
+		if len(elements) == 1 && interrupts(elements[0]) {
 			return elements[0]
 		}
 
 		return &object.Array{Elements: elements}
 
 	case *ast.IndexExpression:
-		left := Eval(node.Left, env)
-		if isError(left) {
-			return left
-		}
-
-		index := Eval(node.Index, env)
-		if isError(index) {
-			return index
-		}
-
-		return evalIndexExpression(left, index)
+		result, _ := evalChain(node, env)
+		return result
 
 	case *ast.HashLiteral:
 		return evalHashLiteral(node, env)
@@ -143,29 +231,260 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 	return nil
 }
 
-func applyFunction(fn object.Object, args []object.Object) object.Object {
+// namedArgument is a name: value argument after evaluation.
+type namedArgument struct {
+	name  string
+	value object.Object
+}
+
+// applyFunction calls fn from code running in env, at the call site site. A reaction runs in a new
+// frame on top of the caller's; an error leaving it for the first time records the frames that were running.
+func applyFunction(fn object.Object, env *object.Environment, site token.Position, args []object.Object, named ...namedArgument) object.Object {
 	switch fn := fn.(type) {
 	case *object.Reaction:
-		extendedEnv := extendFunctionEnv(fn, args)
+		frame := &object.Frame{Name: reactionName(fn), Site: site, Caller: env.Frame()}
+
+		extendedEnv, err := extendFunctionEnv(fn, frame, args, named)
+		if err != nil {
+			return err
+		}
+
 		evaluated := Eval(fn.Body, extendedEnv)
+		if err, ok := evaluated.(*object.Error); ok && err.Stack == nil {
+			err.Stack = frame.Stack()
+		}
+
 		return unwrapReturnValue(evaluated)
 
 	case *object.Builtin:
+		if len(named) > 0 {
+			return newError(object.ARGUMENT_ERROR, "builtin functions do not take named arguments, got %s", named[0].name)
+		}
+
 		return fn.Fn(args...)
 
 	default:
-		return newError("not a function: %s", fn.Type())
+		return newError(object.TYPE_ERROR, "not a function: %s", fn.Type())
+	}
+}
+
+// nameReaction gives a reaction written directly as the value of atom name = ... that name.
+func nameReaction(val object.Object, value ast.Expression, name string) {
+	if reaction, ok := val.(*object.Reaction); ok {
+		if _, literal := value.(*ast.ReactionLiteral); literal {
+			reaction.Name = name
+		}
+	}
+}
+
+func reactionName(fn *object.Reaction) string {
+	if fn.Name == "" {
+		return "<anonymous>"
 	}
+
+	return fn.Name
 }
 
-func extendFunctionEnv(fn *object.Reaction, args []object.Object) *object.Environment {
+// extendFunctionEnv binds the parameters of fn: positional arguments first, then named ones,
+// then defaults for whatever is still missing. Defaults are evaluated in the new environment,
+// so they can refer to the parameters before them.
+func extendFunctionEnv(fn *object.Reaction, frame *object.Frame, args []object.Object, named []namedArgument) (*object.Environment, *object.Error) {
 	env := object.NewEnclosedEnvironment(fn.Env)
+	env.EnterFrame(frame)
+
+	bound := make([]object.Object, len(fn.Parameters))
+
+	var rest *ast.Parameter
+	required, max := 0, 0
+
+	for _, param := range fn.Parameters {
+		switch {
+		case param.Rest:
+			rest = param
+		case param.Default == nil:
+			required++
+			max++
+		default:
+			max++
+		}
+	}
+
+	if (len(args) > max && rest == nil) || (len(args) < required && len(named) == 0) {
+		return nil, newError(object.ARGUMENT_ERROR, "wrong number of arguments. got=%d, want=%s", len(args), arity(required, max, rest != nil))
+	}
+
+	for i, param := range fn.Parameters {
+		if param.Rest {
+			elements := []object.Object{}
+			if len(args) > i {
+				elements = append(elements, args[i:]...)
+			}
+
+			bound[i] = &object.Array{Elements: elements}
+			break
+		}
+
+		if i < len(args) {
+			bound[i] = args[i]
+		}
+	}
+
+	for _, arg := range named {
+		i := parameterIndex(fn.Parameters, arg.name)
+
+		switch {
+		case i < 0:
+			return nil, newError(object.ARGUMENT_ERROR, "unknown parameter name: %s", arg.name)
+		case bound[i] != nil:
+			return nil, newError(object.ARGUMENT_ERROR, "argument for parameter %s given twice", arg.name)
+		}
+
+		bound[i] = arg.value
+	}
+
+	for i, param := range fn.Parameters {
+		if bound[i] == nil {
+			if param.Default == nil {
+				return nil, newError(object.ARGUMENT_ERROR, "missing argument for parameter %s", param.String())
+			}
+
+			value := Eval(param.Default, env)
+			if err, ok := value.(*object.Error); ok {
+				return nil, err
+			}
+
+			bound[i] = value
+		}
+
+		if param.Pattern != nil {
+			if err, ok := bindPattern(param.Pattern, bound[i], env, object.ATOM_BINDING).(*object.Error); ok {
+				return nil, err
+			}
+
+			continue
+		}
+
+		env.Set(param.Name.Value, bound[i])
+	}
+
+	return env, nil
+}
+
+// bindPattern binds the names in pattern to the matching pieces of val.
+// It returns nil when everything was bound, or the error that stopped it.
+func bindPattern(pattern ast.Pattern, val object.Object, env *object.Environment, kind object.BindingKind) object.Object {
+	switch pattern := pattern.(type) {
+	case *ast.Identifier:
+		if !env.Define(pattern.Value, val, kind) {
+			return newError(object.NAME_ERROR, "cannot redeclare molecule %s", pattern.Value)
+		}
+
+	case *ast.ArrayPattern:
+		arr, ok := val.(*object.Array)
+		if !ok {
+			return newError(object.TYPE_ERROR, "cannot destructure %s as an array", val.Type())
+		}
+
+		for i, element := range pattern.Elements {
+			var item object.Object
+			if i < len(arr.Elements) {
+				item = arr.Elements[i]
+			}
+
+			if err := bindPatternElement(element, item, env, kind); err != nil {
+				return err
+			}
+		}
+
+		if pattern.Rest != nil {
+			rest := []object.Object{}
+			if len(arr.Elements) > len(pattern.Elements) {
+				rest = append(rest, arr.Elements[len(pattern.Elements):]...)
+			}
+
+			return bindPattern(pattern.Rest, &object.Array{Elements: rest}, env, kind)
+		}
+
+	case *ast.HashPattern:
+		if exception, ok := val.(*object.Exception); ok {
+			val = exceptionFields(exception)
+		}
+
+		hash, ok := val.(*object.Hash)
+		if !ok {
+			return newError(object.TYPE_ERROR, "cannot destructure %s as a hash", val.Type())
+		}
+
+		taken := map[object.HashKey]bool{}
+
+		for _, entry := range pattern.Entries {
+			key := (&object.String{Value: entry.Key.Value}).HashKey()
+			taken[key] = true
+
+			var item object.Object
+			if pair, ok := hash.Pairs[key]; ok {
+				item = pair.Value
+			}
+
+			if err := bindPatternElement(entry.PatternElement, item, env, kind); err != nil {
+				return err
+			}
+		}
+
+		if pattern.Rest != nil {
+			rest := &object.Hash{Pairs: map[object.HashKey]object.HashPair{}}
+
+			for key, pair := range hash.Pairs {
+				if !taken[key] {
+					rest.Pairs[key] = pair
+				}
+			}
+
+			return bindPattern(pattern.Rest, rest, env, kind)
+		}
+	}
+
+	return nil
+}
+
+// bindPatternElement binds one element of a pattern. A missing item (nil) gets the element's
+// default, evaluated after the elements before it are bound, or NULL when there is none.
+func bindPatternElement(element *ast.PatternElement, item object.Object, env *object.Environment, kind object.BindingKind) object.Object {
+	if item == nil {
+		item = NULL
+
+		if element.Default != nil {
+			item = Eval(element.Default, env)
+			if interrupts(item) {
+				return item
+			}
+		}
+	}
+
+	return bindPattern(element.Target, item, env, kind)
+}
 
-	for paramIdx, param := range fn.Parameters {
-		env.Set(param.Value, args[paramIdx])
+// parameterIndex finds the parameter that a named argument refers to; rest parameters cannot be named.
+func parameterIndex(params []*ast.Parameter, name string) int {
+	for i, param := range params {
+		if param.Name != nil && param.Name.Value == name && !param.Rest {
+			return i
+		}
 	}
 
-	return env
+	return -1
+}
+
+// arity describes how many positional arguments a reaction takes, for error messages.
+func arity(required, max int, rest bool) string {
+	switch {
+	case rest:
+		return fmt.Sprintf("at least %d", required)
+	case required == max:
+		return fmt.Sprintf("%d", max)
+	default:
+		return fmt.Sprintf("%d..%d", required, max)
+	}
 }
 
 func unwrapReturnValue(obj object.Object) object.Object {
@@ -176,10 +495,24 @@ func unwrapReturnValue(obj object.Object) object.Object {
 	return obj
 }
 
+// evalInfixExpression applies a binary operator.
+//
+// Numbers follow these promotion rules:
+//   - INTEGER op INTEGER stays an INTEGER, so / truncates towards zero.
+//     When the result does not fit in 64 bits it becomes a BIGINT, and a BIGINT
+//     result that fits again becomes an INTEGER, so both behave as one integer type.
+//   - if either side is a FLOAT, the other side is converted to FLOAT and the
+//     arithmetic is done in floating point, producing a FLOAT.
+//   - comparisons (<, >, <=, >=, ==, !=) between an INTEGER and a FLOAT compare the
+//     numeric values, so 1 == 1.0 is true.
 func evalInfixExpression(operator string, left, right object.Object) object.Object {
 	switch {
 	case left.Type() == object.INTEGER_OBJ && right.Type() == object.INTEGER_OBJ:
 		return evalIntegerInfixExpression(operator, left, right)
+	case isInteger(left) && isInteger(right):
+		return evalBigIntInfixExpression(operator, left, right)
+	case isNumber(left) && isNumber(right):
+		return evalFloatInfixExpression(operator, left, right)
 	case left.Type() == object.STRING_OBJ && right.Type() == object.STRING_OBJ:
 		return evalStringInfixExpression(operator, left, right)
 	case operator == "==":
@@ -187,9 +520,83 @@ func evalInfixExpression(operator string, left, right object.Object) object.Obje
 	case operator == "!=":
 		return nativeBoolToBooleanObject(left != right)
 	case left.Type() != right.Type():
-		return newError("type mismatch: %s %s %s", left.Type(), operator, right.Type())
+		return newError(object.TYPE_ERROR, "type mismatch: %s %s %s", left.Type(), operator, right.Type())
+	default:
+		return newError(object.TYPE_ERROR, "unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+}
+
+// evalLogicalExpression evaluates && and ||. The right operand is only evaluated when the left one
+// does not already decide the result, and the result is always a BOOLEAN.
+func evalLogicalExpression(node *ast.InfixExpression, env *object.Environment) object.Object {
+	left := Eval(node.Left, env)
+	if interrupts(left) {
+		return left
+	}
+
+	if isTruthy(left) == (node.Operator == "||") {
+		return nativeBoolToBooleanObject(isTruthy(left))
+	}
+
+	right := Eval(node.Right, env)
+	if interrupts(right) {
+		return right
+	}
+
+	return nativeBoolToBooleanObject(isTruthy(right))
+}
+
+// evalNullishExpression evaluates a ?? b: the right operand is only evaluated when the left one is null.
+func evalNullishExpression(node *ast.InfixExpression, env *object.Environment) object.Object {
+	left := Eval(node.Left, env)
+	if left != NULL {
+		return left
+	}
+
+	return Eval(node.Right, env)
+}
+
+// evalChain evaluates a chain of index and call expressions such as a.b?.c(x).
+// When a ?. link finds null, the chain stops there and the whole chain is null;
+// skipped reports that, so the links further out are skipped too.
+func evalChain(node ast.Expression, env *object.Environment) (result object.Object, skipped bool) {
+	switch node := node.(type) {
+	case *ast.IndexExpression:
+		left, skipped := evalChain(node.Left, env)
+		if skipped || interrupts(left) {
+			return left, skipped
+		}
+
+		if node.Optional && left == NULL {
+			return NULL, true
+		}
+
+		index := Eval(node.Index, env)
+		if interrupts(index) {
+			return index, false
+		}
+
+		return withPosition(evalIndexExpression(left, index), node), false
+
+	case *ast.CallExpression:
+		function, skipped := evalChain(node.Function, env)
+		if skipped || interrupts(function) {
+			return function, skipped
+		}
+
+		if node.Optional && function == NULL {
+			return NULL, true
+		}
+
+		args, named, err := evalArguments(node.Arguments, env)
+		if err != nil {
+			return err, false
+		}
+
+		return withPosition(applyFunction(function, env, node.Pos(), args, named...), node), false
+
 	default:
-		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+		return Eval(node, env), false
 	}
 }
 
@@ -202,90 +609,258 @@ func evalIntegerInfixExpression(
 
 	switch operator {
 	case "+":
-		return &object.Integer{Value: leftVal + rightVal}
+		sum := leftVal + rightVal
+		if (leftVal >= 0) == (rightVal >= 0) && (sum >= 0) != (leftVal >= 0) {
+			return evalBigIntInfixExpression(operator, left, right)
+		}
+		return &object.Integer{Value: sum}
 	case "-":
-		return &object.Integer{Value: leftVal - rightVal}
+		diff := leftVal - rightVal
+		if (leftVal >= 0) != (rightVal >= 0) && (diff >= 0) != (leftVal >= 0) {
+			return evalBigIntInfixExpression(operator, left, right)
+		}
+		return &object.Integer{Value: diff}
 	case "*":
-		return &object.Integer{Value: leftVal * rightVal}
+		product := leftVal * rightVal
+		if leftVal != 0 && (product/leftVal != rightVal || leftVal == -1 && rightVal == math.MinInt64) {
+			return evalBigIntInfixExpression(operator, left, right)
+		}
+		return &object.Integer{Value: product}
 	case "/":
+		if rightVal == 0 {
+			return newError(object.ARITHMETIC_ERROR, "division by zero")
+		}
+		if leftVal == math.MinInt64 && rightVal == -1 {
+			return evalBigIntInfixExpression(operator, left, right)
+		}
 		return &object.Integer{Value: leftVal / rightVal}
+	case "%":
+		if rightVal == 0 {
+			return newError(object.ARITHMETIC_ERROR, "division by zero")
+		}
+		return &object.Integer{Value: leftVal % rightVal}
 	case "<":
 		return nativeBoolToBooleanObject(leftVal < rightVal)
 	case ">":
 		return nativeBoolToBooleanObject(leftVal > rightVal)
+	case "<=":
+		return nativeBoolToBooleanObject(leftVal <= rightVal)
+	case ">=":
+		return nativeBoolToBooleanObject(leftVal >= rightVal)
 	case "==":
 		return nativeBoolToBooleanObject(leftVal == rightVal)
 	case "!=":
 		return nativeBoolToBooleanObject(leftVal != rightVal)
 	default:
-		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+		return newError(object.TYPE_ERROR, "unknown operator: %s %s %s", left.Type(), operator, right.Type())
 	}
 }
 
-func evalStringInfixExpression(
+// evalBigIntInfixExpression does integer arithmetic with arbitrary precision.
+// It is used when either side is a BIGINT or when int64 arithmetic would overflow.
+// Results that fit in an int64 again are demoted back to INTEGER.
+func evalBigIntInfixExpression(
 	operator string,
 	left, right object.Object,
 ) object.Object {
-	if operator != "+" {
-		return newError("unknown operator: %s %s %s",
-			left.Type(), operator, right.Type())
-	}
-
-	leftVal := left.(*object.String).Value
-
-	rightVal := right.(*object.String).Value
-
-	return &object.String{Value: leftVal + rightVal}
-}
-
-func evalBlockStatement(block *ast.BlockStatement, env *object.Environment) object.Object {
-	var result object.Object
+	leftVal := toBigInt(left)
+	rightVal := toBigInt(right)
 
-	for _, statement := range block.Statements {
-		result = Eval(statement, env)
-
-		if result != nil {
-			rt := result.Type()
-
-			if rt == object.PRODUCE_VALUE_OBJ || rt == object.ERROR_OBJ {
-				return result
-			}
+	switch operator {
+	case "+":
+		return newInteger(new(big.Int).Add(leftVal, rightVal))
+	case "-":
+		return newInteger(new(big.Int).Sub(leftVal, rightVal))
+	case "*":
+		return newInteger(new(big.Int).Mul(leftVal, rightVal))
+	case "/":
+		if rightVal.Sign() == 0 {
+			return newError(object.ARITHMETIC_ERROR, "division by zero")
+		}
+		return newInteger(new(big.Int).Quo(leftVal, rightVal))
+	case "%":
+		if rightVal.Sign() == 0 {
+			return newError(object.ARITHMETIC_ERROR, "division by zero")
 		}
+		return newInteger(new(big.Int).Rem(leftVal, rightVal))
+	case "<":
+		return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) < 0)
+	case ">":
+		return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) > 0)
+	case "<=":
+		return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) <= 0)
+	case ">=":
+		return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) >= 0)
+	case "==":
+		return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) == 0)
+	case "!=":
+		return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) != 0)
+	default:
+		return newError(object.TYPE_ERROR, "unknown operator: %s %s %s", left.Type(), operator, right.Type())
 	}
-
-	return result
 }
 
-func evalProgram(stmts []ast.Statement, env *object.Environment) object.Object {
-	var result object.Object
+func isInteger(obj object.Object) bool {
+	return obj.Type() == object.INTEGER_OBJ || obj.Type() == object.BIGINT_OBJ
+}
 
-	for _, stmt := range stmts {
-		result = Eval(stmt, env)
+// toBigInt converts an INTEGER or BIGINT to a *big.Int, callers must check isInteger first.
+func toBigInt(obj object.Object) *big.Int {
+	switch obj := obj.(type) {
+	case *object.Integer:
+		return big.NewInt(obj.Value)
+	case *object.BigInt:
+		return obj.Value
+	default:
+		return new(big.Int)
+	}
+}
 
-		switch result := result.(type) {
-		case *object.ProduceValue:
-			return result.Value
-		case *object.Error:
-			return result
-		}
+// newInteger returns value as an INTEGER when it fits in an int64 and as a BIGINT otherwise.
+func newInteger(value *big.Int) object.Object {
+	if value.IsInt64() {
+		return &object.Integer{Value: value.Int64()}
 	}
 
-	return result
+	return &object.BigInt{Value: value}
 }
 
-// func evalReactionStatement(rs *ast.ReactionStatement, env *object.Environment) {
-// 	reaction := &object.Reaction{Parameters: rs.Parameters, Body: rs.Body, Env: env}
-// 	env.Set(rs.Name.Value, reaction)
-// }
+func evalFloatInfixExpression(
+	operator string,
+	left, right object.Object,
+) object.Object {
+	leftVal := toFloat(left)
+	rightVal := toFloat(right)
 
-func evalPrefixExpression(operator string, right object.Object) object.Object {
 	switch operator {
-	case "!":
-		return evalBangOperatorExpression(right)
+	case "+":
+		return &object.Float{Value: leftVal + rightVal}
 	case "-":
-		return evalMinusOperatorExpression(right)
+		return &object.Float{Value: leftVal - rightVal}
+	case "*":
+		return &object.Float{Value: leftVal * rightVal}
+	case "/":
+		return &object.Float{Value: leftVal / rightVal}
+	case "%":
+		return &object.Float{Value: math.Mod(leftVal, rightVal)}
+	case "<":
+		return nativeBoolToBooleanObject(leftVal < rightVal)
+	case ">":
+		return nativeBoolToBooleanObject(leftVal > rightVal)
+	case "<=":
+		return nativeBoolToBooleanObject(leftVal <= rightVal)
+	case ">=":
+		return nativeBoolToBooleanObject(leftVal >= rightVal)
+	case "==":
+		return nativeBoolToBooleanObject(leftVal == rightVal)
+	case "!=":
+		return nativeBoolToBooleanObject(leftVal != rightVal)
+	default:
+		return newError(object.TYPE_ERROR, "unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+}
+
+func isNumber(obj object.Object) bool {
+	return isInteger(obj) || obj.Type() == object.FLOAT_OBJ
+}
+
+// toFloat converts a number to float64, callers must check isNumber first.
+func toFloat(obj object.Object) float64 {
+	switch obj := obj.(type) {
+	case *object.Integer:
+		return float64(obj.Value)
+	case *object.BigInt:
+		value, _ := new(big.Float).SetInt(obj.Value).Float64()
+		return value
+	case *object.Float:
+		return obj.Value
 	default:
-		return newError("unknown operator: %s%s", operator, right.Type())
+		return 0
+	}
+}
+
+func evalStringInfixExpression(
+	operator string,
+	left, right object.Object,
+) object.Object {
+	if operator != "+" {
+		return newError(object.TYPE_ERROR, "unknown operator: %s %s %s",
+			left.Type(), operator, right.Type())
+	}
+
+	leftVal := left.(*object.String).Value
+
+	rightVal := right.(*object.String).Value
+
+	return &object.String{Value: leftVal + rightVal}
+}
+
+func evalInterpolatedString(node *ast.InterpolatedString, env *object.Environment) object.Object {
+	var out bytes.Buffer
+
+	for _, part := range node.Parts {
+		value := Eval(part, env)
+		if interrupts(value) {
+			return value
+		}
+
+		if value != nil {
+			out.WriteString(value.Inspect())
+		}
+	}
+
+	return &object.String{Value: out.String()}
+}
+
+func evalBlockStatement(block *ast.BlockStatement, env *object.Environment) object.Object {
+	var result object.Object
+
+	for _, statement := range block.Statements {
+		result = Eval(statement, env)
+
+		if result != nil {
+			rt := result.Type()
+
+			if rt == object.PRODUCE_VALUE_OBJ || rt == object.ERROR_OBJ ||
+				rt == object.BREAK_OBJ || rt == object.CONTINUE_OBJ {
+				return result
+			}
+		}
+	}
+
+	return result
+}
+
+func evalProgram(stmts []ast.Statement, env *object.Environment) object.Object {
+	var result object.Object
+
+	for _, stmt := range stmts {
+		result = Eval(stmt, env)
+
+		switch result := result.(type) {
+		case *object.ProduceValue:
+			return result.Value
+		case *object.Error:
+			return result
+		}
+	}
+
+	return result
+}
+
+// func evalReactionStatement(rs *ast.ReactionStatement, env *object.Environment) {
+// 	reaction := &object.Reaction{Parameters: rs.Parameters, Body: rs.Body, Env: env}
+// 	env.Set(rs.Name.Value, reaction)
+// }
+
+func evalPrefixExpression(operator string, right object.Object) object.Object {
+	switch operator {
+	case "!":
+		return evalBangOperatorExpression(right)
+	case "-":
+		return evalMinusOperatorExpression(right)
+	default:
+		return newError(object.TYPE_ERROR, "unknown operator: %s%s", operator, right.Type())
 	}
 }
 
@@ -303,13 +878,19 @@ func evalBangOperatorExpression(right object.Object) object.Object {
 }
 
 func evalMinusOperatorExpression(right object.Object) object.Object {
-	if right.Type() != object.INTEGER_OBJ {
-		return newError("unknown operator: -%s", right.Type())
+	switch right := right.(type) {
+	case *object.Integer:
+		if right.Value == math.MinInt64 {
+			return newInteger(new(big.Int).Neg(big.NewInt(right.Value)))
+		}
+		return &object.Integer{Value: -right.Value}
+	case *object.BigInt:
+		return newInteger(new(big.Int).Neg(right.Value))
+	case *object.Float:
+		return &object.Float{Value: -right.Value}
+	default:
+		return newError(object.TYPE_ERROR, "unknown operator: -%s", right.Type())
 	}
-
-	value := right.(*object.Integer).Value
-
-	return &object.Integer{Value: -value}
 }
 
 func nativeBoolToBooleanObject(input bool) *object.Boolean {
@@ -323,7 +904,7 @@ func nativeBoolToBooleanObject(input bool) *object.Boolean {
 func evalIfExpression(ie *ast.IfExpression, env *object.Environment) object.Object {
 	condition := Eval(ie.Condition, env)
 
-	if isError(condition) {
+	if interrupts(condition) {
 		return condition
 	}
 
@@ -336,6 +917,516 @@ func evalIfExpression(ie *ast.IfExpression, env *object.Environment) object.Obje
 	}
 }
 
+// throwValue turns the value of a throw statement into an error. A caught exception is thrown
+// again as it was; anything else becomes the payload of a new error.
+func throwValue(value object.Object) *object.Error {
+	if exception, ok := value.(*object.Exception); ok {
+		err := *exception.Error
+		return &err
+	}
+
+	return &object.Error{Message: value.Inspect(), Kind: object.GENERIC_ERROR, Payload: value}
+}
+
+// evalTryStatement runs the try block and, if it fails, the catch block with the error bound
+// as an exception. The finally block always runs last; a produce, break, continue or error
+// coming out of it replaces the outcome of the rest.
+func evalTryStatement(ts *ast.TryStatement, env *object.Environment) object.Object {
+	result := Eval(ts.Block, env)
+
+	if err, ok := result.(*object.Error); ok && ts.Catch != nil {
+		scope := object.NewEnclosedEnvironment(env)
+
+		if ts.Param != nil {
+			scope.Set(ts.Param.Value, &object.Exception{Error: err})
+		}
+
+		result = Eval(ts.Catch, scope)
+	}
+
+	if ts.Finally != nil {
+		if final := Eval(ts.Finally, env); final != nil {
+			switch final.Type() {
+			case object.PRODUCE_VALUE_OBJ, object.ERROR_OBJ, object.BREAK_OBJ, object.CONTINUE_OBJ:
+				return final
+			}
+		}
+	}
+
+	return result
+}
+
+// exceptionFields returns the fields of a caught exception as a hash, so that they can be read
+// with e.message or e["kind"] and taken apart by hash patterns.
+func exceptionFields(exception *object.Exception) *object.Hash {
+	err := exception.Error
+
+	var payload object.Object = NULL
+	if err.Payload != nil {
+		payload = err.Payload
+	}
+
+	var position object.Object = NULL
+	if err.Pos.IsValid() {
+		position = &object.String{Value: err.Pos.String()}
+	}
+
+	stack := &object.Array{Elements: []object.Object{}}
+	for _, frame := range err.Stack {
+		stack.Elements = append(stack.Elements, &object.String{Value: frame.Name})
+	}
+
+	fields := map[string]object.Object{
+		"message":   &object.String{Value: err.Message},
+		"kind":      &object.String{Value: err.Kind},
+		"payload":   payload,
+		"stack":     stack,
+		"position":  position,
+		"traceback": &object.String{Value: err.Traceback()},
+	}
+
+	hash := &object.Hash{Pairs: map[object.HashKey]object.HashPair{}}
+	for name, value := range fields {
+		key := &object.String{Value: name}
+		hash.Pairs[key.HashKey()] = object.HashPair{Key: key, Value: value}
+	}
+
+	return hash
+}
+
+// evalMatchExpression evaluates the body of the first arm whose pattern matches the subject and
+// whose guard is truthy, in a scope holding the names the pattern bound. With no such arm it is NULL.
+func evalMatchExpression(me *ast.MatchExpression, env *object.Environment) object.Object {
+	subject := Eval(me.Subject, env)
+	if interrupts(subject) {
+		return subject
+	}
+
+	for _, arm := range me.Arms {
+		scope := object.NewEnclosedEnvironment(env)
+
+		matched, err := matchPattern(arm.Pattern, subject, scope)
+		if err != nil {
+			return err
+		}
+
+		if !matched {
+			continue
+		}
+
+		if arm.Guard != nil {
+			guard := Eval(arm.Guard, scope)
+			if interrupts(guard) {
+				return guard
+			}
+
+			if !isTruthy(guard) {
+				continue
+			}
+		}
+
+		return Eval(arm.Body, scope)
+	}
+
+	return NULL
+}
+
+// matchPattern reports whether val has the shape of pattern, binding names in env as it goes.
+// Arrays must have as many elements as the pattern (at least, with a ...rest or defaults) and
+// hashes must have every key listed without a default. The error is set when evaluating a default fails.
+func matchPattern(pattern ast.Pattern, val object.Object, env *object.Environment) (bool, object.Object) {
+	switch pattern := pattern.(type) {
+	case *ast.WildcardPattern:
+		return true, nil
+
+	case *ast.Identifier:
+		env.Set(pattern.Value, val)
+		return true, nil
+
+	case *ast.LiteralPattern:
+		literal := Eval(pattern.Value, env)
+		if interrupts(literal) {
+			return false, literal
+		}
+
+		// strings have no == operator, so they are compared here
+		if str, ok := literal.(*object.String); ok {
+			other, ok := val.(*object.String)
+			return ok && other.Value == str.Value, nil
+		}
+
+		return evalInfixExpression("==", literal, val) == TRUE, nil
+
+	case *ast.ArrayPattern:
+		arr, ok := val.(*object.Array)
+		if !ok {
+			return false, nil
+		}
+
+		required := 0
+		for i, element := range pattern.Elements {
+			if element.Default == nil {
+				required = i + 1
+			}
+		}
+
+		if len(arr.Elements) < required || pattern.Rest == nil && len(arr.Elements) > len(pattern.Elements) {
+			return false, nil
+		}
+
+		for i, element := range pattern.Elements {
+			var item object.Object
+			if i < len(arr.Elements) {
+				item = arr.Elements[i]
+			}
+
+			if matched, err := matchPatternElement(element, item, env); !matched {
+				return false, err
+			}
+		}
+
+		if pattern.Rest != nil {
+			rest := []object.Object{}
+			if len(arr.Elements) > len(pattern.Elements) {
+				rest = append(rest, arr.Elements[len(pattern.Elements):]...)
+			}
+
+			env.Set(pattern.Rest.Value, &object.Array{Elements: rest})
+		}
+
+		return true, nil
+
+	case *ast.HashPattern:
+		if exception, ok := val.(*object.Exception); ok {
+			val = exceptionFields(exception)
+		}
+
+		hash, ok := val.(*object.Hash)
+		if !ok {
+			return false, nil
+		}
+
+		taken := map[object.HashKey]bool{}
+
+		for _, entry := range pattern.Entries {
+			key := (&object.String{Value: entry.Key.Value}).HashKey()
+			taken[key] = true
+
+			pair, ok := hash.Pairs[key]
+			if !ok && entry.Default == nil {
+				return false, nil
+			}
+
+			if matched, err := matchPatternElement(entry.PatternElement, pair.Value, env); !matched {
+				return false, err
+			}
+		}
+
+		if pattern.Rest != nil {
+			rest := &object.Hash{Pairs: map[object.HashKey]object.HashPair{}}
+
+			for key, pair := range hash.Pairs {
+				if !taken[key] {
+					rest.Pairs[key] = pair
+				}
+			}
+
+			env.Set(pattern.Rest.Value, rest)
+		}
+
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// matchPatternElement matches one element of a pattern; a missing item (nil) is replaced by the default first.
+func matchPatternElement(element *ast.PatternElement, item object.Object, env *object.Environment) (bool, object.Object) {
+	if item == nil {
+		item = NULL
+
+		if element.Default != nil {
+			item = Eval(element.Default, env)
+			if interrupts(item) {
+				return false, item
+			}
+		}
+	}
+
+	return matchPattern(element.Target, item, env)
+}
+
+// evalWhileStatement runs the body in a fresh scope on every pass, so bindings made inside
+// do not leak. A produce, an error or a break meant for an outer loop stops the loop and is handed to the caller.
+func evalWhileStatement(ws *ast.WhileStatement, env *object.Environment) object.Object {
+	label := labelOf(ws.Label)
+
+	for {
+		condition := Eval(ws.Condition, env)
+		if interrupts(condition) {
+			return condition
+		}
+
+		if !isTruthy(condition) {
+			return NULL
+		}
+
+		if result := evalLoopBody(ws.Body, env, label, nil); result != nil {
+			return result
+		}
+	}
+}
+
+func evalForStatement(fs *ast.ForStatement, env *object.Environment) object.Object {
+	iterable := Eval(fs.Iterable, env)
+	if interrupts(iterable) {
+		return iterable
+	}
+
+	label := labelOf(fs.Label)
+
+	// body binds the loop variables for one item and runs the body.
+	body := func(key, value object.Object) object.Object {
+		return evalLoopBody(fs.Body, env, label, func(scope *object.Environment) {
+			if fs.Key != nil {
+				scope.Set(fs.Key.Value, key)
+			}
+
+			scope.Set(fs.Value.Value, value)
+		})
+	}
+
+	switch iterable := iterable.(type) {
+	case *object.Array:
+		for i, element := range iterable.Elements {
+			if result := body(&object.Integer{Value: int64(i)}, element); result != nil {
+				return result
+			}
+		}
+
+	case *object.String:
+		i := int64(0)
+
+		for _, r := range iterable.Value {
+			if result := body(&object.Integer{Value: i}, &object.String{Value: string(r)}); result != nil {
+				return result
+			}
+
+			i++
+		}
+
+	case *object.Range:
+		i := int64(0)
+
+		for n := iterable.Start; i < iterable.Len(); n += iterable.Step {
+			if result := body(&object.Integer{Value: i}, &object.Integer{Value: n}); result != nil {
+				return result
+			}
+
+			i++
+		}
+
+	case *object.Hash:
+		for _, pair := range sortedPairs(iterable) {
+			key, value := pair.Key, pair.Value
+
+			if fs.Key == nil {
+				value = key
+			}
+
+			if result := body(key, value); result != nil {
+				return result
+			}
+		}
+
+	default:
+		return newError(object.TYPE_ERROR, "cannot iterate over %s", iterable.Type())
+	}
+
+	return NULL
+}
+
+// evalLoopBody runs one pass of a loop body in its own scope, after bind has set up the loop variables.
+// It returns nil to keep looping, NULL when the loop labelled label is broken out of,
+// or the produce value, error or outer break/continue that ends the loop.
+func evalLoopBody(body *ast.BlockStatement, env *object.Environment, label string, bind func(*object.Environment)) object.Object {
+	scope := object.NewEnclosedEnvironment(env)
+
+	if bind != nil {
+		bind(scope)
+	}
+
+	switch result := evalBlockStatement(body, scope).(type) {
+	case *object.ProduceValue, *object.Error:
+		return result
+	case *object.Break:
+		if result.Label == "" || result.Label == label {
+			return NULL
+		}
+
+		return result
+	case *object.Continue:
+		if result.Label == "" || result.Label == label {
+			return nil
+		}
+
+		return result
+	}
+
+	return nil
+}
+
+func labelOf(label *ast.Identifier) string {
+	if label == nil {
+		return ""
+	}
+
+	return label.Value
+}
+
+// sortedPairs returns the pairs of a hash ordered by key, so iterating a hash is repeatable.
+// Keys of the same type are compared by value; different types are grouped by type name.
+func sortedPairs(hash *object.Hash) []object.HashPair {
+	pairs := make([]object.HashPair, 0, len(hash.Pairs))
+
+	for _, pair := range hash.Pairs {
+		pairs = append(pairs, pair)
+	}
+
+	sort.Slice(pairs, func(i, j int) bool {
+		a, b := pairs[i].Key, pairs[j].Key
+
+		if isNumber(a) && isNumber(b) {
+			if isInteger(a) && isInteger(b) {
+				return toBigInt(a).Cmp(toBigInt(b)) < 0
+			}
+
+			return toFloat(a) < toFloat(b)
+		}
+
+		if a.Type() != b.Type() {
+			return a.Type() < b.Type()
+		}
+
+		return a.Inspect() < b.Inspect()
+	})
+
+	return pairs
+}
+
+// evalAssignExpression updates an existing binding, array element or hash entry; a compound operator
+// such as += first combines the current value with the new one using the matching infix operator.
+func evalAssignExpression(node *ast.AssignExpression, env *object.Environment) object.Object {
+	switch target := node.Target.(type) {
+	case *ast.Identifier:
+		return evalIdentifierAssignment(node, target, env)
+	case *ast.IndexExpression:
+		return evalIndexAssignment(node, target, env)
+	default:
+		return newError(object.TYPE_ERROR, "cannot assign to %s", node.Target.String())
+	}
+}
+
+func evalIdentifierAssignment(node *ast.AssignExpression, target *ast.Identifier, env *object.Environment) object.Object {
+	name := target.Value
+
+	val := Eval(node.Value, env)
+	if interrupts(val) {
+		return val
+	}
+
+	kind, ok := env.Kind(name)
+	if !ok {
+		return newError(object.NAME_ERROR, "assignment to undeclared identifier: %s", name)
+	}
+
+	if kind == object.MOLECULE_BINDING {
+		return newError(object.NAME_ERROR, "cannot assign to molecule %s", name)
+	}
+
+	if node.Operator != "=" {
+		current, _ := env.Get(name)
+
+		val = evalCompoundOperator(node.Operator, current, val)
+		if isError(val) {
+			return val
+		}
+	}
+
+	env.Assign(name, val)
+
+	return val
+}
+
+// evalIndexAssignment changes the array or hash in place, so every reference to it sees the new value.
+func evalIndexAssignment(node *ast.AssignExpression, target *ast.IndexExpression, env *object.Environment) object.Object {
+	left := Eval(target.Left, env)
+	if interrupts(left) {
+		return left
+	}
+
+	index := Eval(target.Index, env)
+	if interrupts(index) {
+		return index
+	}
+
+	val := Eval(node.Value, env)
+	if interrupts(val) {
+		return val
+	}
+
+	switch left := left.(type) {
+	case *object.Array:
+		idx, ok := index.(*object.Integer)
+		if !ok {
+			return newError(object.TYPE_ERROR, "array index must be INTEGER, got %s", index.Type())
+		}
+
+		if idx.Value < 0 || idx.Value >= int64(len(left.Elements)) {
+			return newError(object.INDEX_ERROR, "index %d out of range for array of length %d", idx.Value, len(left.Elements))
+		}
+
+		if node.Operator != "=" {
+			val = evalCompoundOperator(node.Operator, left.Elements[idx.Value], val)
+			if isError(val) {
+				return val
+			}
+		}
+
+		left.Elements[idx.Value] = val
+
+	case *object.Hash:
+		key, ok := index.(object.Hashable)
+		if !ok {
+			return newError(object.TYPE_ERROR, "unusable as hash key: %s", index.Type())
+		}
+
+		if node.Operator != "=" {
+			pair, ok := left.Pairs[key.HashKey()]
+			if !ok {
+				return newError(object.INDEX_ERROR, "key not found: %s", index.Inspect())
+			}
+
+			val = evalCompoundOperator(node.Operator, pair.Value, val)
+			if isError(val) {
+				return val
+			}
+		}
+
+		left.Pairs[key.HashKey()] = object.HashPair{Key: index, Value: val}
+
+	default:
+		return newError(object.TYPE_ERROR, "index assignment not supported: %s", left.Type())
+	}
+
+	return val
+}
+
+// evalCompoundOperator applies the arithmetic part of a compound assignment, "+" for "+=".
+func evalCompoundOperator(operator string, current, val object.Object) object.Object {
+	return evalInfixExpression(strings.TrimSuffix(operator, "="), current, val)
+}
+
 func evalIdentifier(node *ast.Identifier, env *object.Environment) object.Object {
 	if val, ok := env.Get(node.Value); ok {
 		return val
@@ -345,15 +1436,62 @@ func evalIdentifier(node *ast.Identifier, env *object.Environment) object.Object
 		return builtin
 	}
 
-	return newError("identifier not found: " + node.Value)
+	return newError(object.NAME_ERROR, "identifier not found: %s", node.Value)
+}
+
+// evalArguments evaluates the arguments of a call, splitting them into positional and named ones.
+func evalArguments(exps []ast.Expression, env *object.Environment) ([]object.Object, []namedArgument, object.Object) {
+	args := []object.Object{}
+	named := []namedArgument{}
+
+	for _, e := range exps {
+		if arg, ok := e.(*ast.NamedArgument); ok {
+			value := Eval(arg.Value, env)
+			if interrupts(value) {
+				return nil, nil, value
+			}
+
+			named = append(named, namedArgument{name: arg.Name.Value, value: value})
+			continue
+		}
+
+		if spread, ok := e.(*ast.SpreadElement); ok {
+			values, err := evalSpread(spread, env, "arguments")
+			if err != nil {
+				return nil, nil, err
+			}
+
+			args = append(args, values...)
+			continue
+		}
+
+		value := Eval(e, env)
+		if interrupts(value) {
+			return nil, nil, value
+		}
+
+		args = append(args, value)
+	}
+
+	return args, named, nil
 }
 
 func evalExpressions(exps []ast.Expression, env *object.Environment) []object.Object {
 	var result []object.Object
 
 	for _, e := range exps {
+		if spread, ok := e.(*ast.SpreadElement); ok {
+			values, err := evalSpread(spread, env, "an array")
+			if err != nil {
+				return []object.Object{err}
+			}
+
+			result = append(result, values...)
+			continue
+		}
+
 		evaluated := Eval(e, env)
-		if isError(evaluated) {
+		if interrupts(evaluated) {
 			return []object.Object{evaluated}
 		}
 		result = append(result, evaluated)
@@ -362,14 +1500,46 @@ func evalExpressions(exps []ast.Expression, env *object.Environment) []object.Ob
 	return result
 }
 
+// evalSpread evaluates ...value in an array literal or call and returns the elements it
+// stands for. into names the destination for the error message.
+func evalSpread(spread *ast.SpreadElement, env *object.Environment, into string) ([]object.Object, object.Object) {
+	value := Eval(spread.Value, env)
+	if interrupts(value) {
+		return nil, value
+	}
+
+	switch value := value.(type) {
+	case *object.Array:
+		return value.Elements, nil
+
+	case *object.Range:
+		if value.Count() > maxSpreadLength {
+			return nil, withPosition(newError(object.VALUE_ERROR, "cannot spread %s into %s: a spread range is limited to %d elements",
+				value.Inspect(), into, maxSpreadLength), spread)
+		}
+
+		elements := make([]object.Object, 0, value.Count())
+
+		for i, n := int64(0), value.Start; i < value.Len(); i, n = i+1, n+value.Step {
+			elements = append(elements, &object.Integer{Value: n})
+		}
+
+		return elements, nil
+	}
+
+	return nil, withPosition(newError(object.TYPE_ERROR, "cannot spread %s into %s", value.Type(), into), spread)
+}
+
 func evalIndexExpression(left, index object.Object) object.Object {
 	switch {
 	case left.Type() == object.ARRAY_OBJ && index.Type() == object.INTEGER_OBJ:
 		return evalArrayIndexExpression(left, index)
 	case left.Type() == object.HASH_OBJ:
 		return evalHashIndexExpression(left, index)
+	case left.Type() == object.EXCEPTION_OBJ:
+		return evalHashIndexExpression(exceptionFields(left.(*object.Exception)), index)
 	default:
-		return newError("index operator not supported: %s", left.Type())
+		return newError(object.TYPE_ERROR, "index operator not supported: %s", left.Type())
 	}
 }
 
@@ -390,22 +1560,42 @@ func evalArrayIndexExpression(array, index object.Object) object.Object {
 func evalHashLiteral(node *ast.HashLiteral, env *object.Environment) object.Object {
 	pairs := make(map[object.HashKey]object.HashPair)
 
-	for keyNode, valueNode := range node.Pairs {
+	for _, keyNode := range node.Order {
+		if spread, ok := keyNode.(*ast.SpreadElement); ok {
+			value := Eval(spread.Value, env)
+			if interrupts(value) {
+				return value
+			}
+
+			hash, ok := value.(*object.Hash)
+			if !ok {
+				return withPosition(newError(object.TYPE_ERROR, "cannot spread %s into a hash", value.Type()), spread)
+			}
+
+			for hashed, pair := range hash.Pairs {
+				pairs[hashed] = pair
+			}
+
+			continue
+		}
+
+		valueNode := node.Pairs[keyNode]
+
 		key := Eval(keyNode, env)
 
-		if isError(key) {
+		if interrupts(key) {
 			return key
 		}
 
 		hashKey, ok := key.(object.Hashable)
 
 		if !ok {
-			return newError("unusable as hash key: %s", key.Type())
+			return newError(object.TYPE_ERROR, "unusable as hash key: %s", key.Type())
 		}
 
 		value := Eval(valueNode, env)
 
-		if isError(value) {
+		if interrupts(value) {
 			return value
 		}
 
@@ -422,7 +1612,7 @@ func evalHashIndexExpression(hash, index object.Object) object.Object {
 	key, ok := index.(object.Hashable)
 
 	if !ok {
-		return newError("unusable as hash key: %s", index.Type())
+		return newError(object.TYPE_ERROR, "unusable as hash key: %s", index.Type())
 	}
 
 	pair, ok := hashObject.Pairs[key.HashKey()]
@@ -447,6 +1637,16 @@ func isTruthy(obj object.Object) bool {
 	}
 }
 
-func newError(format string, a ...interface{}) *object.Error {
-	return &object.Error{Message: fmt.Sprintf(format, a...)}
+// withPosition stamps an error with the position of node, unless a node deeper in the tree already did.
+func withPosition(obj object.Object, node ast.Node) object.Object {
+	if err, ok := obj.(*object.Error); ok && !err.Pos.IsValid() {
+		err.Pos = node.Pos()
+	}
+
+	return obj
+}
+
+// newError creates an error of the given kind, one of the object.*_ERROR constants.
+func newError(kind, format string, a ...interface{}) *object.Error {
+	return &object.Error{Message: fmt.Sprintf(format, a...), Kind: kind}
 }
@@ -4,6 +4,7 @@ import (
 	"atom_script/lexer"
 	"atom_script/object"
 	"atom_script/parser"
+	"strings"
 	"testing"
 )
 
@@ -35,6 +36,153 @@ func TestEvalIntergerExpression(t *testing.T) {
 	}
 }
 
+func TestEvalFloatExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected float64
+	}{
+		{"1.008", 1.008},
+		{"-2.5", -2.5},
+		{"1.5 + 1.5", 3.0},
+		{"2 * 1.008", 2.016},
+		{"1.008 * 2", 2.016},
+		{"7 / 2.0", 3.5},
+		{"10 - 0.5", 9.5},
+		{"float(3)", 3.0},
+		{`float("6.02")`, 6.02},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		testFloatObject(t, evaluated, tt.expected)
+	}
+}
+
+func testFloatObject(t *testing.T, obj object.Object, expected float64) bool {
+	result, ok := obj.(*object.Float)
+	if !ok {
+		t.Errorf("object is not Float. got=%T (%+v)", obj, obj)
+		return false
+	}
+
+	if result.Value != expected {
+		t.Errorf("object has wrong value. got=%g, want=%g",
+			result.Value, expected)
+		return false
+	}
+
+	return true
+}
+
+func TestNumericConversionsAndPromotion(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"7 / 2", 7 / 2},
+		{"int(3.99)", 3},
+		{"int(-3.99)", -3},
+		{`int("42")`, 42},
+		{"1 == 1.0", true},
+		{"1 != 1.5", true},
+		{"1 < 1.5", true},
+		{"2.5 > 3", false},
+		{`int("abc")`, `cannot convert "abc" to INTEGER`},
+		{"int(true)", "argument to `int` not supported, got BOOLEAN"},
+		{"1.5 + true", "type mismatch: FLOAT + BOOLEAN"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("object is not Error. got=%T (%+v)", evaluated, evaluated)
+				continue
+			}
+			if errObj.Message != expected {
+				t.Errorf("wrong error message. expected=%q, got=%q",
+					expected, errObj.Message)
+			}
+		}
+	}
+}
+
+func TestBigIntPromotion(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"9223372036854775807 + 1", "9223372036854775808"},
+		{"-9223372036854775807 - 2", "-9223372036854775809"},
+		{"602214076 * 1000000000 * 1000000000000000", "602214076000000000000000000000000"},
+		{"atom n = 0 - 9223372036854775807 - 1; -n", "9223372036854775808"},
+		{"atom n = 0 - 9223372036854775807 - 1; n / -1", "9223372036854775808"},
+		{"int(6.022e23)", "602200000000000027262976"},
+		{`int("123456789012345678901234567890")`, "123456789012345678901234567890"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		result, ok := evaluated.(*object.BigInt)
+		if !ok {
+			t.Errorf("object is not BigInt. got=%T (%+v)", evaluated, evaluated)
+			continue
+		}
+
+		if result.Value.String() != tt.expected {
+			t.Errorf("object has wrong value. got=%s, want=%s",
+				result.Value.String(), tt.expected)
+		}
+	}
+}
+
+func TestBigIntDemotionAndComparison(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"(9223372036854775807 + 1) - 1", 9223372036854775807},
+		{"(9223372036854775807 * 10) / 10", 9223372036854775807},
+		{"9223372036854775807 + 1 > 9223372036854775807", true},
+		{"9223372036854775807 + 1 == 9223372036854775807 + 1", true},
+		{"9223372036854775807 < 9223372036854775807 + 1", true},
+		{"(9223372036854775807 + 1) * 1.0 > 9.2e18", true},
+		{`atom big = 9223372036854775807 + 1; {big: 7}[9223372036854775807 + 1]`, 7},
+		{"1 / 0", "division by zero"},
+		{"(9223372036854775807 + 1) / 0", "division by zero"},
+		{`(9223372036854775807 + 1) + "x"`, "type mismatch: BIGINT + STRING"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("object is not Error. got=%T (%+v)", evaluated, evaluated)
+				continue
+			}
+			if errObj.Message != expected {
+				t.Errorf("wrong error message. expected=%q, got=%q",
+					expected, errObj.Message)
+			}
+		}
+	}
+}
+
 func testIntegerObject(t *testing.T, obj object.Object, expected int64) bool {
 	result, ok := obj.(*object.Integer)
 	if !ok {
@@ -358,6 +506,46 @@ func TestStringLiteral(t *testing.T) {
 	}
 }
 
+func TestStringEscapesAndRawStrings(t *testing.T) {
+	input := `"H\u{2082}O: \"water\"\n" + ` + "`C:\\path`"
+
+	evaluated := testEval(input)
+
+	str, ok := evaluated.(*object.String)
+	if !ok {
+		t.Fatalf("object is not String. got=%T (%+v)", evaluated, evaluated)
+	}
+
+	if str.Value != "H₂O: \"water\"\nC:\\path" {
+		t.Errorf("String has wrong value. got=%q", str.Value)
+	}
+}
+
+func TestInterpolatedStrings(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`atom m = 1; "mass is ${m}"`, "mass is 1"},
+		{`atom el = {"name": "helium"}; "mass of ${el["name"]} is ${2 * 2}"`, "mass of helium is 4"},
+		{`"${[1, 2]} ${true} ${"nested ${1 + 1}"}"`, "[1, 2] true nested 2"},
+		{`"cost: \${5}"`, "cost: ${5}"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		str, ok := evaluated.(*object.String)
+		if !ok {
+			t.Fatalf("object is not String. got=%T (%+v)", evaluated, evaluated)
+		}
+
+		if str.Value != tt.expected {
+			t.Errorf("String has wrong value. want=%q, got=%q", tt.expected, str.Value)
+		}
+	}
+}
+
 func TestStringConcatenation(t *testing.T) {
 	input := `"Hello" + " " + "World!"`
 
@@ -382,6 +570,14 @@ func TestBuiltinFunctions(t *testing.T) {
 		{`len("hello world")`, 11},
 		{`len(1)`, "argument to `len` not supported, got INTEGER"},
 		{`len("one", "two")`, "wrong number of arguments. got=2, want=1"},
+		{`len("héllo")`, 6},
+		{`runeLen("héllo")`, 5},
+		{`runeLen("Δλ")`, 2},
+		{`runeLen(1)`, "argument to `runeLen` must be STRING, got INTEGER"},
+		{`len(runes("Δλx"))`, 3},
+		{`runes("Δλx")[1]`, "λ"},
+		{`runeAt("Δλx", 0)`, "Δ"},
+		{`runeAt("Δλx", 3)`, nil},
 	}
 
 	for _, tt := range tests {
@@ -390,7 +586,16 @@ func TestBuiltinFunctions(t *testing.T) {
 		switch expected := tt.expected.(type) {
 		case int:
 			testIntegerObject(t, evaluated, int64(expected))
+		case nil:
+			testNullObject(t, evaluated)
 		case string:
+			if str, ok := evaluated.(*object.String); ok {
+				if str.Value != expected {
+					t.Errorf("String has wrong value. want=%q, got=%q", expected, str.Value)
+				}
+				continue
+			}
+
 			errObj, ok := evaluated.(*object.Error)
 			if !ok {
 				t.Errorf("object is not Error. got=%T (%+v)", evaluated, evaluated)
@@ -556,6 +761,18 @@ func TestHashIndexExpressions(t *testing.T) {
 			`{false: 5}[false]`,
 			5,
 		},
+		{
+			`{1: 5}[1.0]`,
+			5,
+		},
+		{
+			`{1.0: 5}[1]`,
+			5,
+		},
+		{
+			`{1: 5}[1.5]`,
+			nil,
+		},
 	}
 
 	for _, tt := range tests {
@@ -568,3 +785,790 @@ func TestHashIndexExpressions(t *testing.T) {
 		}
 	}
 }
+
+func TestErrorPositions(t *testing.T) {
+	tests := []struct {
+		input       string
+		expectedPos string
+	}{
+		{"5 + true;", "1:3"},
+		{"atom a = 1;\n\ta + foobar;", "2:6"},
+		{"reaction f(x) {\n  -x\n}\nf(true);", "2:3"},
+		{`len(1)`, "1:4"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned. got=%T(%+v)",
+				evaluated, evaluated)
+			continue
+		}
+
+		if errObj.Pos.String() != tt.expectedPos {
+			t.Errorf("wrong error position. expected=%s, got=%s",
+				tt.expectedPos, errObj.Pos)
+		}
+	}
+}
+
+func TestLoops(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`while (false) { 1 }`, nil},
+		{`while (true) { produce 5; }`, 5},
+		{`for (x in []) { produce x; }`, nil},
+		{`for (x in [1, 2, 3]) { if (x > 1) { produce x; } }`, 2},
+		{`for (i, x in [10, 20, 30]) { if (x > 10) { produce i; } }`, 1},
+		{`for (i, c in "héllo") { if (i == 2) { produce c; } }`, "l"},
+		{`for (k in {"b": 2, "a": 1}) { produce k; }`, "a"},
+		{`for (k, v in {"b": 2, "a": 1}) { produce v; }`, 1},
+		{`for (n in range(3)) { if (n == 2) { produce n; } }`, 2},
+		{`for (i, n in range(10, 0, -3)) { if (n < 5) { produce i * 100 + n; } }`, 204},
+		{`len(range(1, 10, 4))`, 3},
+		{`for (n in range(0, 10, 9223372036854775807)) { produce n + 1; }`, 1},
+		{`len(range(9223372036854775807, -9223372036854775807, -9223372036854775807))`, 2},
+		{`len(range(-9223372036854775807, 9223372036854775807))`, "range(-9223372036854775807, 9223372036854775807, 1) has too many elements"},
+		{`reaction find(xs) { for (x in xs) { if (x > 2) { produce x; } } produce 0; } find([1, 5]) + find([1])`, 5},
+		{`for (x in [1]) { atom y = x; } y`, "identifier not found: y"},
+		{`for (x in 5) { x }`, "cannot iterate over INTEGER"},
+		{`range(1, 2, 0)`, "step of `range` must not be 0"},
+		{`while (1 + true) { 1 }`, "type mismatch: INTEGER + BOOLEAN"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case nil:
+			testNullObject(t, evaluated)
+		case string:
+			switch obj := evaluated.(type) {
+			case *object.String:
+				if obj.Value != expected {
+					t.Errorf("String has wrong value. want=%q, got=%q", expected, obj.Value)
+				}
+			case *object.Error:
+				if obj.Message != expected {
+					t.Errorf("wrong error message. expected=%q, got=%q", expected, obj.Message)
+				}
+			default:
+				t.Errorf("object is not String or Error. got=%T (%+v)", evaluated, evaluated)
+			}
+		}
+	}
+}
+
+func TestBreakAndContinue(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`while (true) { break; }`, nil},
+		{`for (x in [1, 2]) { break; produce x; }`, nil},
+		{`for (x in range(10)) { if (x < 3) { continue; } produce x; }`, 3},
+		{`for (x in range(10)) { for (y in range(10)) { if (y > 1) { break; } } if (x == 4) { produce x; } }`, 4},
+		{`outer: for (i in range(3)) { for (j in range(3)) { if (j == 1) { continue outer; } if (i == 2) { produce i * 10 + j; } } }`, 20},
+		{`outer: for (i in range(3)) { while (true) { break outer; } produce 1; }`, nil},
+		{`reaction f() { for (x in [1, 2, 3]) { if (x == 2) { break; } } produce 7; } f()`, 7},
+		{`atom r = 0; for (i in [1, 2, 3]) { atom v = if (i == 2) { break } else { i }; r += v; } r`, 1},
+		{`atom r = 0; for (i in [1, 2, 3]) { atom v = if (i == 2) { continue } else { i }; r += v; } r`, 4},
+		{`atom r = 0; for (i in [1, 2, 3]) { r += 10 * (if (i == 3) { break } else { i }); } r`, 30},
+		{`atom r = 0; for (i in [1, 2, 3]) { r = r + (if (i == 1) { continue } else { i }); } r`, 5},
+		{`reaction id(x) { x } atom n = 0; for (i in [1, 2, 3]) { n += 1; id(if (true) { break } else { 1 }); } n`, 1},
+		{`atom r = []; for (i in [1, 2, 3]) { r = [...r, if (i == 2) { continue } else { i }]; } len(r)`, 2},
+		{`reaction f() { atom v = if (true) { produce 5 } else { 1 }; v + 1 } f()`, 5},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case nil:
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
+func TestAssignExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`atom x = 1; x = 2; x`, 2},
+		{`atom x = 1; x = 2`, 2},
+		{`atom x = 1; atom y = 0; x = y = 5; x + y`, 10},
+		{`atom x = 10; x += 5; x -= 3; x *= 2; x /= 4; x`, 6},
+		{`atom x = 17; x %= 5; x`, 2},
+		{`atom s = "H"; s += "e"; s`, "He"},
+		{`atom total = 0; for (n in range(1, 5)) { total += n; } total`, 10},
+		{`atom n = 0; while (n < 10) { n += 3; } n`, 12},
+		{`reaction counter() { atom count = 0; reaction() { count += 1; } } atom next = counter(); next(); next(); next()`, 3},
+		{`atom x = 1; reaction f() { atom x = 5; x = 7; x } f() + x`, 8},
+		{`x = 1`, "assignment to undeclared identifier: x"},
+		{`y += 1`, "assignment to undeclared identifier: y"},
+		{`len = 1`, "assignment to undeclared identifier: len"},
+		{`atom x = 1; x /= 0`, "division by zero"},
+		{`atom x = 1; x += true`, "type mismatch: INTEGER + BOOLEAN"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			switch obj := evaluated.(type) {
+			case *object.String:
+				if obj.Value != expected {
+					t.Errorf("String has wrong value. want=%q, got=%q", expected, obj.Value)
+				}
+			case *object.Error:
+				if obj.Message != expected {
+					t.Errorf("wrong error message. expected=%q, got=%q", expected, obj.Message)
+				}
+			default:
+				t.Errorf("object is not String or Error. got=%T (%+v)", evaluated, evaluated)
+			}
+		}
+	}
+}
+
+func TestMoleculeBindings(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`molecule c = 1; c = 2;`, "cannot assign to molecule c"},
+		{`molecule c = 1; c *= 2;`, "cannot assign to molecule c"},
+		{`molecule c = 1; molecule c = 2;`, "cannot redeclare molecule c"},
+		{`molecule c = 1; atom c = 2;`, "cannot redeclare molecule c"},
+		{`molecule c = 1; reaction c() { 1 }`, "cannot redeclare molecule c"},
+		{`molecule c = 1; reaction f() { c = 2; } f()`, "cannot assign to molecule c"},
+		{`molecule c = 1; reaction f() { atom c = 2; c = 3; c } f() + c`, 4},
+		{`molecule c = 1; for (x in [5]) { atom c = x; c += 1; } c`, 1},
+		{`atom a = 1; molecule a = 2; a`, 2},
+		{`atom a = 1; molecule a = 2; a = 3`, "cannot assign to molecule a"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+				continue
+			}
+
+			if errObj.Message != expected {
+				t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+			}
+		}
+	}
+}
+
+func TestMoleculeAcrossPrograms(t *testing.T) {
+	env := object.NewEnvironment()
+
+	for _, input := range []string{`molecule c = 1;`, `c = 2;`} {
+		l := lexer.New(input)
+		p := parser.New(l)
+		program := p.ParseProgram()
+
+		if len(p.Errors()) != 0 {
+			t.Fatalf("unexpected parser errors for %q: %v", input, p.Errors())
+		}
+
+		evaluated := Eval(program, env)
+
+		if input == `c = 2;` {
+			errObj, ok := evaluated.(*object.Error)
+			if !ok || errObj.Message != "cannot assign to molecule c" {
+				t.Errorf("expected the runtime to reject the assignment. got=%T(%+v)", evaluated, evaluated)
+			}
+		}
+	}
+}
+
+func TestIndexAssignment(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`atom a = [1, 2, 3]; a[1] = 5; a[1]`, 5},
+		{`atom a = [1, 2, 3]; a[0] += 10; a[0]`, 11},
+		{`atom a = [1, 2, 3]; atom b = a; b[2] = 9; a[2]`, 9},
+		{`atom h = {"H": 1}; h["He"] = 2; h["He"]`, 2},
+		{`atom h = {"H": 1}; h.H = 3; h["H"]`, 3},
+		{`atom h = {"H": 1}; h.H *= 4; h.H`, 4},
+		{`molecule h = {}; h.count = 1; h.count`, 1},
+		{`atom t = {"H": {"mass": 1}}; t.H.mass = 2; t["H"]["mass"]`, 2},
+		{`reaction set(h) { h.x = 7; } atom h = {}; set(h); h.x`, 7},
+		{`atom sq = [0, 0, 0, 0]; for (i in range(4)) { sq[i] = i * i; } sq[3]`, 9},
+		{`atom a = [1]; a[1] = 2`, "index 1 out of range for array of length 1"},
+		{`atom a = [1]; a[-1] = 2`, "index -1 out of range for array of length 1"},
+		{`atom a = [1]; a["x"] = 2`, "array index must be INTEGER, got STRING"},
+		{`atom h = {}; h[[1]] = 2`, "unusable as hash key: ARRAY"},
+		{`atom h = {}; h.x += 1`, "key not found: x"},
+		{`atom s = "abc"; s[0] = "x"`, "index assignment not supported: STRING"},
+		{`atom el = {"mass": 1}; el.mass`, 1},
+		{`atom el = {"mass": 1}; el.charge`, nil},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case nil:
+			testNullObject(t, evaluated)
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+				continue
+			}
+
+			if errObj.Message != expected {
+				t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+			}
+		}
+	}
+}
+
+func TestLogicalAndComparisonExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`true && true`, true},
+		{`true && false`, false},
+		{`false || true`, true},
+		{`false || false`, false},
+		{`1 && "a"`, true},
+		{`0 || false`, true},
+		{`false && (1 + true)`, false},
+		{`true || missing`, true},
+		{`atom n = 0; reaction bump() { n += 1; true } false && bump(); true || bump(); n`, 0},
+		{`atom n = 0; reaction bump() { n += 1; true } true && bump(); false || bump(); n`, 2},
+		{`true && (1 + true)`, "type mismatch: INTEGER + BOOLEAN"},
+		{`1 <= 1`, true},
+		{`2 <= 1`, false},
+		{`1 >= 2`, false},
+		{`2.5 >= 2`, true},
+		{`9223372036854775807 + 1 >= 9223372036854775807`, true},
+		{`17 % 5`, 2},
+		{`-7 % 3`, -1},
+		{`7.5 % 2`, 1.5},
+		{`5 % 0`, "division by zero"},
+		{`1 < 2 && 2 < 3 || false`, true},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case float64:
+			testFloatObject(t, evaluated, expected)
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+				continue
+			}
+
+			if errObj.Message != expected {
+				t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+			}
+		}
+	}
+}
+
+func TestNullAndOptionalChaining(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`null`, nil},
+		{`null == null`, true},
+		{`atom x = null; x != null`, false},
+		{`!null`, true},
+		{`null ?? 5`, 5},
+		{`0 ?? 5`, 0},
+		{`false ?? 5`, false},
+		{`{"a": 1}["b"] ?? 2`, 2},
+		{`atom n = 0; reaction bump() { n += 1; } 1 ?? bump(); n`, 0},
+		{`atom el = {"name": "H"}; el?.name`, "H"},
+		{`atom el = null; el?.name`, nil},
+		{`atom el = null; el?.isotopes[0].mass`, nil},
+		{`atom el = null; el?.["name"]`, nil},
+		{`atom el = {"isotopes": null}; el.isotopes?.[0]`, nil},
+		{`atom el = {}; el.isotopes?.[0] ?? "none"`, "none"},
+		{`atom f = null; f?.(1)`, nil},
+		{`atom f = reaction(x) { x * 2 }; f?.(4)`, 8},
+		{`atom n = 0; reaction bump() { n += 1; } atom f = null; f?.(bump()); n`, 0},
+		{`atom el = {}; el.isotopes.count`, "index operator not supported: NULL"},
+		{`atom el = {}; el.isotopes[0]?.mass`, "index operator not supported: NULL"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case nil:
+			testNullObject(t, evaluated)
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			switch obj := evaluated.(type) {
+			case *object.String:
+				if obj.Value != expected {
+					t.Errorf("String has wrong value. want=%q, got=%q", expected, obj.Value)
+				}
+			case *object.Error:
+				if obj.Message != expected {
+					t.Errorf("wrong error message. expected=%q, got=%q", expected, obj.Message)
+				}
+			default:
+				t.Errorf("object is not String or Error. got=%T (%+v)", evaluated, evaluated)
+			}
+		}
+	}
+}
+
+func TestConditionalAndPipeExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`true ? 1 : 2`, 1},
+		{`false ? 1 : 2`, 2},
+		{`null ? 1 : 2`, 2},
+		{`atom x = 5; x > 3 ? x * 2 : x`, 10},
+		{`atom n = 0; reaction bump() { n += 1; } true ? 1 : bump(); false ? bump() : 1; n`, 0},
+		{`atom x = 0; x == 0 ? "zero" : x > 0 ? "positive" : "negative"`, "zero"},
+		{`(1 + true) ? 1 : 2`, "type mismatch: INTEGER + BOOLEAN"},
+		{`[1, 2, 3] |> len`, 3},
+		{`[1, 2, 3] |> rest |> first`, 2},
+		{`[1, 2] |> push(3) |> last`, 3},
+		{`reaction double(x) { x * 2 } 4 |> double |> double`, 16},
+		{`reaction sub(a, b) { a - b } 10 |> sub(3)`, 7},
+		{`5 |> reaction(x) { x + 1 }`, 6},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			switch obj := evaluated.(type) {
+			case *object.String:
+				if obj.Value != expected {
+					t.Errorf("String has wrong value. want=%q, got=%q", expected, obj.Value)
+				}
+			case *object.Error:
+				if obj.Message != expected {
+					t.Errorf("wrong error message. expected=%q, got=%q", expected, obj.Message)
+				}
+			default:
+				t.Errorf("object is not String or Error. got=%T (%+v)", evaluated, evaluated)
+			}
+		}
+	}
+}
+
+func TestArrowFunctions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{`atom double = x => x * 2; double(21)`, 42},
+		{`atom add = (a, b) => a + b; add(2, 3)`, 5},
+		{`atom answer = () => 42; answer()`, 42},
+		{`atom f = (x) => { atom y = x * 2; produce y + 1; }; f(3)`, 7},
+		{`atom adder = x => y => x + y; adder(2)(5)`, 7},
+		{`4 |> (x => x * x)`, 16},
+		{`reaction apply(f, x) { f(x) } apply(n => n - 1, 10)`, 9},
+	}
+
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestReactionParameters(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`reaction f(a, b = 2) { a + b } f(1)`, 3},
+		{`reaction f(a, b = 2) { a + b } f(1, 5)`, 6},
+		{`reaction f(a, b = a * 10) { b } f(3)`, 30},
+		{`reaction f(a, ...rest) { len(rest) } f(1, 2, 3)`, 2},
+		{`reaction f(a, ...rest) { len(rest) } f(1)`, 0},
+		{`reaction f(...xs) { xs } f(1, 2)[1]`, 2},
+		{`reaction f(a, b = 2, c = 3) { a * 100 + b * 10 + c } f(1, c: 5)`, 125},
+		{`reaction f(a, b) { a - b } f(b: 1, a: 10)`, 9},
+		{`atom sum = (a, b = 1) => a + b; sum(a: 4)`, 5},
+		{`reaction f(a, b) { a } f(1)`, "wrong number of arguments. got=1, want=2"},
+		{`reaction f(a) { a } f(1, 2)`, "wrong number of arguments. got=2, want=1"},
+		{`reaction f(a, b = 2) { a } f()`, "wrong number of arguments. got=0, want=1..2"},
+		{`reaction f(a, b = 2) { a } f(1, 2, 3)`, "wrong number of arguments. got=3, want=1..2"},
+		{`reaction f(a, b, ...c) { a } f(1)`, "wrong number of arguments. got=1, want=at least 2"},
+		{`reaction f(a, b) { a } f(1, c: 2)`, "unknown parameter name: c"},
+		{`reaction f(a, b) { a } f(1, a: 2)`, "argument for parameter a given twice"},
+		{`reaction f(a, b) { a } f(a: 1)`, "missing argument for parameter b"},
+		{`reaction f(a, ...rest) { a } f(1, rest: 2)`, "unknown parameter name: rest"},
+		{`reaction f(a = 1 + true) { a } f()`, "type mismatch: INTEGER + BOOLEAN"},
+		{`len(x: [1])`, "builtin functions do not take named arguments, got x"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("no error object returned for %q. got=%T(%+v)", tt.input, evaluated, evaluated)
+				continue
+			}
+
+			if errObj.Message != expected {
+				t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+			}
+		}
+	}
+}
+
+func TestDestructuring(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`atom [a, b] = [1, 2]; a * 10 + b`, 12},
+		{`atom [a, b, c] = [1, 2]; c`, nil},
+		{`atom [a, b = a + 5] = [1]; b`, 6},
+		{`atom [a, ...rest] = [1, 2, 3]; len(rest) * 10 + rest[1]`, 23},
+		{`atom [a, b, ...rest] = [1]; len(rest)`, 0},
+		{`atom [[a, b], c] = [[1, 2], 3]; a + b + c`, 6},
+		{`atom {x, y: z} = {"x": 1, "y": 2}; x * 10 + z`, 12},
+		{`atom {x, w = 4} = {"x": 1}; x + w`, 5},
+		{`atom {x} = {}; x`, nil},
+		{`atom {x, ...others} = {"x": 1, "y": 2, "z": 3}; others["y"] + others["z"]`, 5},
+		{`atom {x, ...others} = {"x": 1, "y": 2}; others["x"]`, nil},
+		{`atom {x: [a, b]} = {"x": [4, 5]}; a + b`, 9},
+		{`molecule [m, n] = [1, 2]; m + n`, 3},
+		{`molecule [m] = [1]; m = 2`, "cannot assign to molecule m"},
+		{`reaction f([a, b], {c}) { a + b + c } f([1, 2], {"c": 3})`, 6},
+		{`reaction f([a, b] = [5, 6]) { a * b } f()`, 30},
+		{`atom g = ([a, b] = [1, 2], {k: v}) => a + b + v; g([3, 4], {"k": 1})`, 8},
+		{`atom g = ([a, b] = [1, 2]) => a + b; g() * 10 + g([3, 4])`, 37},
+		{`atom g = ({name, symbol: sym, mass = 3}) => name + sym + mass; g({"name": 1, "symbol": 2})`, 6},
+		{`atom g = ({el: {z}}) => z; g({"el": {"z": 26}})`, 26},
+		{`atom [a] = 1`, "cannot destructure INTEGER as an array"},
+		{`atom {a} = [1]`, "cannot destructure ARRAY as a hash"},
+		{`reaction f([a]) { a } f({})`, "cannot destructure HASH as an array"},
+		{`reaction f([a], b) { a } f(b: 1)`, "missing argument for parameter [a]"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case nil:
+			testNullObject(t, evaluated)
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("no error object returned for %q. got=%T(%+v)", tt.input, evaluated, evaluated)
+				continue
+			}
+
+			if errObj.Message != expected {
+				t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+			}
+		}
+	}
+}
+
+func TestSpread(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`atom a = [1, 2]; atom b = [3]; len([...a, ...b])`, 3},
+		{`atom a = [1, 2]; [0, ...a, 9][3]`, 9},
+		{`len([...[]])`, 0},
+		{`[...range(3)][2]`, 2},
+		{`atom a = [1]; atom b = [...a]; b[0] = 5; a[0]`, 1},
+		{`atom d = {"x": 1, "y": 2}; atom o = {"y": 20}; {...d, ...o}["y"]`, 20},
+		{`atom d = {"x": 1, "y": 2}; {...d, "x": 10}["x"]`, 10},
+		{`atom d = {"x": 1, "y": 2}; {"x": 10, ...d}["x"]`, 1},
+		{`{...{}}["x"]`, nil},
+		{`reaction f(a, b, c) { a * 100 + b * 10 + c } atom xs = [2, 3]; f(1, ...xs)`, 123},
+		{`reaction f(a, b = 5) { a + b } f(...[1], b: 2)`, 3},
+		{`reaction f(...xs) { len(xs) } f(...[1, 2], 3, ...[4])`, 4},
+		{`len(...[[1, 2]])`, 2},
+		{`reaction f(a) { a } f(...[1, 2])`, "wrong number of arguments. got=2, want=1"},
+		{`[...1]`, "cannot spread INTEGER into an array"},
+		{`puts(..."ab")`, "cannot spread STRING into arguments"},
+		{`{...[1]}`, "cannot spread ARRAY into a hash"},
+		{`[...range(0, 9223372036854775807)]`, "cannot spread range(0, 9223372036854775807, 1) into an array: a spread range is limited to 16777216 elements"},
+		{`[...range(-9223372036854775807, 9223372036854775807)]`, "range(-9223372036854775807, 9223372036854775807, 1) has too many elements"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case nil:
+			testNullObject(t, evaluated)
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("no error object returned for %q. got=%T(%+v)", tt.input, evaluated, evaluated)
+				continue
+			}
+
+			if errObj.Message != expected {
+				t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+			}
+		}
+	}
+}
+
+func TestMatchExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`match 2 { 1 => 10, 2 => 20, _ => 0 }`, 20},
+		{`match 5 { 1 => 10, 2 => 20, _ => 0 }`, 0},
+		{`match 5 { 1 => 10 }`, nil},
+		{`match -3 { -3 => 1, _ => 0 }`, 1},
+		{`match 2.0 { 2 => 1, _ => 0 }`, 1},
+		{`match "He" { "H" => 1, "He" => 2 }`, 2},
+		{`match null { null => 1, _ => 0 }`, 1},
+		{`match 0 { null => 1, false => 2, _ => 3 }`, 3},
+		{`match 7 { n => n * 2 }`, 14},
+		{`match 7 { n if n > 10 => 1, n if n > 5 => 2, _ => 3 }`, 2},
+		{`match 2 { y if ((z) => z * 10)(y) > 15 => 1, _ => 0 }`, 1},
+		{`match [1, 2] { [a] => a, [a, b] => a + b, _ => 0 }`, 3},
+		{`match [1, 2, 3] { [a, b] => 0, [1, ...rest] => len(rest) }`, 2},
+		{`match [1] { [a, b = 5] => a + b }`, 6},
+		{`match [[1, 2], 3] { [[_, b], c] => b * c }`, 6},
+		{`match [] { [] => 1, _ => 0 }`, 1},
+		{`match {"group": 18, "name": "Ne"} { {group: 1} => 1, {group: 18, name} => len(name) }`, 2},
+		{`match {"name": "H"} { {name, mass} => 1, {name} => 2 }`, 2},
+		{`match {"name": "H"} { {name, mass = 1} => mass }`, 1},
+		{`match {"a": 1, "b": 2} { {a, ...rest} => rest["b"] }`, 2},
+		{`match 1 { [a] => 1, {a} => 2, _ => 3 }`, 3},
+		{`atom n = 1; match 5 { n => n }; n`, 1},
+		{`atom r = 0; for (x in [1, 2, 3]) { match x { 2 => { break }, _ => { r += x } } } r`, 1},
+		{`atom r = 0; for (i in [1, 2, 3]) { atom v = match i { 2 => { break }, _ => i }; r += v; } r`, 1},
+		{`atom r = 0; for (i in [1, 2, 3]) { r += match i { 2 => { continue }, _ => i }; } r`, 4},
+		{`reaction f(x) { match x { 0 => { produce 100 }, _ => 1 }; 2 } f(0)`, 100},
+		{`match 1 { 1 if 1 + true => 1 }`, "type mismatch: INTEGER + BOOLEAN"},
+		{`match [] { [a = 1 + true] => a }`, "type mismatch: INTEGER + BOOLEAN"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case nil:
+			testNullObject(t, evaluated)
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("no error object returned for %q. got=%T(%+v)", tt.input, evaluated, evaluated)
+				continue
+			}
+
+			if errObj.Message != expected {
+				t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+			}
+		}
+	}
+}
+
+func TestTryAndThrow(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`try { 1 } catch (e) { 2 }`, 1},
+		{`try { 1 + true } catch (e) { 2 }`, 2},
+		{`try { throw 5 } catch (e) { e.payload }`, 5},
+		{`try { throw "boom" } catch (e) { len(e.message) }`, 4},
+		{`try { throw 1 } catch { 7 }`, 7},
+		{`try { throw 1 } catch (e) { e.nope }`, nil},
+		{`try { throw error("m", "K", [1, 2]) } catch (e) { e.payload[1] }`, 2},
+		{`try { throw error("m") } catch (e) { e.payload }`, nil},
+		{`try { first(1) } catch (e) { match e { {kind: "TypeError"} => 1, _ => 0 } }`, 1},
+		{`try { throw error("no", "LookupError") } catch (e) { atom {kind, message} = e; len(kind) + len(message) }`, 13},
+		{`atom r = 0; try { r = 1 } finally { r += 10 } r`, 11},
+		{`atom r = 0; try { throw 1 } catch (e) { r = 1 } finally { r += 10 } r`, 11},
+		{`reaction f() { try { produce 1 } finally { produce 2 } } f()`, 2},
+		{`reaction f() { try { produce 1 } catch (e) { 0 } } f()`, 1},
+		{`reaction f() { throw 3 } reaction g() { try { f() } catch (e) { e.payload * 10 } } g()`, 30},
+		{`atom r = 0; for (x in [1, 2, 3]) { try { if (x == 2) { throw x } r += x } catch (e) { r += 100 } } r`, 104},
+		{`atom r = 0; for (x in [1, 2, 3]) { try { if (x == 2) { break } } finally { r += x } } r`, 3},
+		{`try { try { throw 1 } catch (e) { throw e } } catch (e) { e.payload + 1 }`, 2},
+		{`try { try { throw 1 } finally { 0 } } catch (e) { e.payload }`, 1},
+		{`throw "boom"`, "boom"},
+		{`throw error("no element", "LookupError")`, "no element"},
+		{`try { throw 1 } finally { 2 }`, "1"},
+		{`try { throw 1 } catch (e) { throw e }`, "1"},
+		{`try { throw 1 } catch (e) { 1 + true }`, "type mismatch: INTEGER + BOOLEAN"},
+		{`try { 1 } catch (e) { 2 } finally { 1 + true }`, "type mismatch: INTEGER + BOOLEAN"},
+		{`error(1)`, "first argument to `error` must be STRING, got INTEGER"},
+		{`error("m", 1)`, "second argument to `error` must be STRING, got INTEGER"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case nil:
+			testNullObject(t, evaluated)
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("no error object returned for %q. got=%T(%+v)", tt.input, evaluated, evaluated)
+				continue
+			}
+
+			if errObj.Message != expected {
+				t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+			}
+		}
+	}
+}
+
+func TestErrorKinds(t *testing.T) {
+	tests := []struct {
+		input string
+		kind  string
+	}{
+		{`1 + true`, object.TYPE_ERROR},
+		{`first(1)`, object.TYPE_ERROR},
+		{`push([1], 2, 3)`, object.ARGUMENT_ERROR},
+		{`reaction f(a) { a } f()`, object.ARGUMENT_ERROR},
+		{`missing`, object.NAME_ERROR},
+		{`molecule m = 1; m = 2`, object.NAME_ERROR},
+		{`[1][5] = 2`, object.INDEX_ERROR},
+		{`atom h = {}; h["x"] += 1`, object.INDEX_ERROR},
+		{`int("abc")`, object.VALUE_ERROR},
+		{`range(0, 5, 0)`, object.VALUE_ERROR},
+		{`[...range(0, 9223372036854775807)]`, object.VALUE_ERROR},
+		{`1 / 0`, object.ARITHMETIC_ERROR},
+		{`throw 1`, object.GENERIC_ERROR},
+		{`throw error("no element", "LookupError")`, "LookupError"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned for %q. got=%T(%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+
+		if errObj.Kind != tt.kind {
+			t.Errorf("wrong kind for %q. expected=%q, got=%q", tt.input, tt.kind, errObj.Kind)
+		}
+	}
+}
+
+func TestErrorStackAndExceptionFields(t *testing.T) {
+	stackTests := []struct {
+		input    string
+		expected []string
+	}{
+		{`reaction inner() { 1 + true } reaction outer() { inner() } outer()`, []string{"inner", "outer"}},
+		{`atom g = () => 1 + true; g()`, []string{"g"}},
+		{`atom g = () => 1 + true; atom h = g; h()`, []string{"g"}},
+		{`(() => 1 + true)()`, []string{"<anonymous>"}},
+		{`reaction f() { throw 1 } reaction g() { try { f() } catch (e) { throw e } } g()`, []string{"f", "g"}},
+		{`1 + true`, nil},
+	}
+
+	for _, tt := range stackTests {
+		evaluated := testEval(tt.input)
+
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned for %q. got=%T(%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+
+		names := []string{}
+		for _, frame := range errObj.Stack {
+			names = append(names, frame.Name)
+		}
+
+		if strings.Join(names, " ") != strings.Join(tt.expected, " ") {
+			t.Errorf("wrong stack for %q. expected=%q, got=%q", tt.input, tt.expected, names)
+		}
+	}
+
+	fieldTests := []struct {
+		input    string
+		expected string
+	}{
+		{`try { throw error("no element", "LookupError") } catch (e) { e }`, "LookupError: no element"},
+		{`try { 1 + true } catch (e) { e.kind }`, "TypeError"},
+		{`try { missing } catch (e) { e["message"] }`, "identifier not found: missing"},
+		{`try {
+  throw 1
+} catch (e) { e.position }`, "2:3"},
+		{`reaction f() { throw 1 } try { f() } catch (e) { e.stack }`, "[f]"},
+		{`try { throw [1, 2] } catch (e) { e.message }`, "[1, 2]"},
+		{`reaction f() {
+  throw 1
+}
+try { f() } catch (e) { e.traceback }`, "ERROR: 2:3: 1\n    at f (2:3)\n    at <top level> (4:8)"},
+	}
+
+	for _, tt := range fieldTests {
+		evaluated := testEval(tt.input)
+
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %q. expected=%q, got=%q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
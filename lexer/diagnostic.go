@@ -0,0 +1,51 @@
+package lexer
+
+import "atom_script/token"
+
+// Diagnostic is a problem the lexer found in the input.
+// The lexer records it, skips or repairs the offending text and keeps going,
+// so a single typo does not hide the rest of the program.
+type Diagnostic struct {
+	Start      token.Position
+	End        token.Position
+	Message    string // what is wrong, e.g. "unterminated string"
+	Text       string // the offending source text
+	Suggestion string // how to fix it, e.g. "did you mean `==`?", may be empty
+}
+
+func (d Diagnostic) String() string {
+	msg := d.Message
+
+	if d.Text != "" {
+		msg += " `" + d.Text + "`"
+	}
+
+	if d.Suggestion != "" {
+		msg += " (" + d.Suggestion + ")"
+	}
+
+	if d.Start.IsValid() {
+		return d.Start.String() + ": " + msg
+	}
+
+	return msg
+}
+
+// suggestions for characters that are not part of the language, mostly look-alikes
+// of real operators that sneak in when code is copied from documents.
+var suggestions = map[rune]string{
+	'#':  "comments start with `//`",
+	'&':  "did you mean `&&`?",
+	'|':  "did you mean `||`?",
+	'\'': "strings use double quotes `\"`",
+	'“':  "did you mean `\"`?",
+	'”':  "did you mean `\"`?",
+	'≠':  "did you mean `!=`?",
+	'−':  "did you mean `-`?",
+	'×':  "did you mean `*`?",
+	'÷':  "did you mean `/`?",
+	'；':  "did you mean `;`?",
+	'，':  "did you mean `,`?",
+	'（':  "did you mean `(`?",
+	'）':  "did you mean `)`?",
+}
@@ -1,53 +1,192 @@
 package lexer
 
-import "atom_script/token"
+import (
+	"atom_script/token"
+	"bufio"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
 
-// Lexer does the lexical analysis or tokenization of the input string.
+// Lexer does the lexical analysis or tokenization of the input.
+// The input is read incrementally from an io.Reader, one rune ahead of the current char.
 type Lexer struct {
-	input        string // input string to be tokenized
-	position     int    // current position in input (points to current char)
-	readPosition int    // current reading position in input (after current char)
-	ch           byte   // current char under examination (ASCII only)
+	reader       *bufio.Reader   // input to be tokenized
+	file         string          // name of the file the input comes from, used in token positions
+	position     int             // current byte offset in input (points to current char)
+	readPosition int             // current reading byte offset in input (after current char)
+	ch           rune            // current char under examination
+	peek         rune            // the char after ch, 0 at EOF
+	peekSize     int             // width of peek in bytes, 0 at EOF
+	line         int             // line of the current char
+	column       int             // column of the current char, counted in runes
+	text         strings.Builder // the chars of the current token read so far
+	err          error           // first error returned by reader, other than io.EOF
+	mode         Mode            // optional behaviour, see SetMode
+	start        token.Position  // where the current token starts
+	diagnostics  []Diagnostic    // problems found so far, see Diagnostics
+
+	// interpolations holds, for every ${ we are inside of, how many { are open in its expression.
+	// A } seen when the innermost count is 0 closes the interpolation and resumes the string.
+	interpolations []int
 }
 
+// Mode is a set of flags that change what the Lexer emits.
+type Mode uint
+
+const (
+	// ScanComments makes the Lexer return comments as token.COMMENT instead of skipping them,
+	// so tools like formatters and doc generators can keep them.
+	ScanComments Mode = 1 << iota
+)
+
 // New creates a new Lexer and initializes it with the input string.
-// readChar() is called to initialize the Lexer's ch field.
 func New(input string) *Lexer {
+	return NewFile("", input)
+}
+
+// NewFile creates a new Lexer for input read from the named file.
+// The file name is recorded in the position of every token.
+func NewFile(file string, input string) *Lexer {
+	return NewReader(file, strings.NewReader(input))
+}
+
+// NewReader creates a new Lexer that tokenizes r as it is read, so large scripts
+// and piped input do not need to be loaded into memory first.
+// file names the input in token positions and may be empty.
+// readChar() is called to initialize the Lexer's ch field.
+func NewReader(file string, r io.Reader) *Lexer {
 	l := &Lexer{
-		input: input,
+		reader: bufio.NewReader(r),
+		file:   file,
+		line:   1,
 	}
 
+	l.readPeek()
 	l.readChar()
 	return l
 }
 
-// readChar reads the next character and advances our position in the input string.
+// Err returns the first error that happened while reading the input, other than io.EOF.
+// The lexer treats a read error as the end of the input.
+func (l *Lexer) Err() error {
+	return l.err
+}
+
+// Diagnostics returns the problems found in the input so far, in the order they were found.
+func (l *Lexer) Diagnostics() []Diagnostic {
+	return l.diagnostics
+}
+
+// diagnose records a problem with the text from start up to the current char.
+func (l *Lexer) diagnose(start token.Position, text string, message string, suggestion string) {
+	l.diagnostics = append(l.diagnostics, Diagnostic{
+		Start:      start,
+		End:        l.pos(),
+		Message:    message,
+		Text:       text,
+		Suggestion: suggestion,
+	})
+}
+
+// SetMode changes the lexing mode for all following tokens.
+func (l *Lexer) SetMode(mode Mode) {
+	l.mode = mode
+}
+
+// readChar moves to the next character and advances our position in the input.
+// Invalid UTF-8 is read as utf8.RuneError one byte at a time.
 func (l *Lexer) readChar() {
-	if l.readPosition >= len(l.input) {
-		l.ch = 0 // EOF (end of file) is reached, 0 is the ASCII code for the "NUL" character
+	if l.ch == '\n' { // the character we are leaving ends a line
+		l.line += 1
+		l.column = 1
 	} else {
-		l.ch = l.input[l.readPosition] // reading the next character
+		l.column += 1
 	}
 
+	if l.position < l.readPosition { // the char we are leaving is a real one, not EOF
+		l.text.WriteRune(l.ch)
+	}
+
+	l.ch = l.peek // at EOF this is 0, the ASCII code for the "NUL" character
+
 	l.position = l.readPosition // position becomes the readPosition
 
-	l.readPosition += 1 // readPosition advances by 1
+	l.readPosition += l.peekSize // readPosition advances by the width of the character
+
+	l.readPeek()
 }
 
-// peekChar returns the next character without advancing our position in the input string.
-func (l *Lexer) peekChar() byte {
-	if l.readPosition >= len(l.input) {
-		return 0
-	} else {
-		return l.input[l.readPosition] // reading the next character
+// readPeek decodes the character after the current one from the reader.
+func (l *Lexer) readPeek() {
+	ch, size, err := l.reader.ReadRune()
+
+	if err != nil {
+		if err != io.EOF && l.err == nil {
+			l.err = err
+		}
+
+		l.peek, l.peekSize = 0, 0
+		return
 	}
+
+	l.peek, l.peekSize = ch, size
+}
+
+// peekChar returns the next character without advancing our position in the input.
+func (l *Lexer) peekChar() rune {
+	return l.peek
 }
 
-// NextToken returns the next token and advances our position in the input string.
+// pos returns the position of the current character.
+func (l *Lexer) pos() token.Position {
+	return token.Position{
+		File:   l.file,
+		Line:   l.line,
+		Column: l.column,
+		Offset: l.position,
+	}
+}
+
+// NextToken returns the next token and advances our position in the input.
+// The token's Start and End are set to the span of source it was read from.
+// Comments are skipped unless the ScanComments mode is set.
+// Characters that cannot start a token are skipped and reported through Diagnostics,
+// so NextToken never returns token.ILLEGAL.
 func (l *Lexer) NextToken() token.Token {
-	var tok token.Token
+	for {
+		l.skipWhitespace()
+
+		l.start = l.pos()
+		l.text.Reset()
+
+		var tok token.Token
+
+		if l.ch == '/' && (l.peekChar() == '/' || l.peekChar() == '*') {
+			tok = l.readComment()
+
+			if l.mode&ScanComments == 0 {
+				continue
+			}
+		} else {
+			tok = l.readToken()
+
+			if tok.Type == token.ILLEGAL { // already diagnosed, resynchronize on the next char
+				continue
+			}
+		}
+
+		tok.Start = l.start
+		tok.End = l.pos()
+
+		return tok
+	}
+}
 
-	l.skipWhitespace()
+// readToken reads the token starting at the current character.
+func (l *Lexer) readToken() token.Token {
+	var tok token.Token
 
 	switch l.ch {
 	case '=':
@@ -57,22 +196,59 @@ func (l *Lexer) NextToken() token.Token {
 				Type:    token.EQ,
 				Literal: "==",
 			}
+
+			if l.peekChar() == '=' {
+				l.readChar()
+				l.diagnose(l.start, "===", "unexpected operator", "did you mean `==`?")
+			}
+		} else if l.peekChar() == '>' {
+			l.readChar()
+			tok = token.Token{Type: token.ARROW, Literal: "=>"}
 		} else {
 			tok = newToken(token.ASSIGN, l.ch)
 		}
 
 	case '+':
-		tok = newToken(token.PLUS, l.ch)
+		tok = l.readOperator(token.PLUS, token.PLUS_ASSIGN)
 	case '(':
 		tok = newToken(token.LPAREN, l.ch)
 	case ')':
 		tok = newToken(token.RPAREN, l.ch)
 	case '{':
+		if n := len(l.interpolations); n > 0 {
+			l.interpolations[n-1] += 1
+		}
+
 		tok = newToken(token.LBRACE, l.ch)
 	case '}':
+		if n := len(l.interpolations); n > 0 {
+			if l.interpolations[n-1] == 0 {
+				l.interpolations = l.interpolations[:n-1]
+				return l.readString(true)
+			}
+
+			l.interpolations[n-1] -= 1
+		}
+
 		tok = newToken(token.RBRACE, l.ch)
 	case ':':
 		tok = newToken(token.COLON, l.ch)
+	case '.':
+		if l.peekChar() != '.' {
+			tok = newToken(token.DOT, l.ch)
+			break
+		}
+
+		l.readChar()
+
+		if l.peekChar() != '.' {
+			l.readChar()
+			l.diagnose(l.start, "..", "unexpected operator", "did you mean `...`?")
+			return token.Token{Type: token.ILLEGAL, Literal: ".."}
+		}
+
+		l.readChar()
+		tok = token.Token{Type: token.ELLIPSIS, Literal: "..."}
 	case ',':
 		tok = newToken(token.COMMA, l.ch)
 	case ';':
@@ -81,7 +257,7 @@ func (l *Lexer) NextToken() token.Token {
 		tok.Literal = ""
 		tok.Type = token.EOF
 	case '-':
-		tok = newToken(token.MINUS, l.ch)
+		tok = l.readOperator(token.MINUS, token.MINUS_ASSIGN)
 	case '!':
 		if l.peekChar() == '=' { // if the next character is '='
 			l.readChar() // read the next character
@@ -89,20 +265,57 @@ func (l *Lexer) NextToken() token.Token {
 				Type:    token.NOT_EQ,
 				Literal: "!=",
 			}
+
+			if l.peekChar() == '=' {
+				l.readChar()
+				l.diagnose(l.start, "!==", "unexpected operator", "did you mean `!=`?")
+			}
 		} else {
 			tok = newToken(token.BANG, l.ch)
 		}
 	case '*':
-		tok = newToken(token.ASTERISK, l.ch)
+		tok = l.readOperator(token.ASTERISK, token.ASTERISK_ASSIGN)
 	case '/':
-		tok = newToken(token.SLASH, l.ch)
+		tok = l.readOperator(token.SLASH, token.SLASH_ASSIGN)
+	case '%':
+		tok = l.readOperator(token.PERCENT, token.PERCENT_ASSIGN)
+	case '?':
+		switch l.peekChar() {
+		case '?':
+			l.readChar()
+			tok = token.Token{Type: token.NULLISH, Literal: "??"}
+		case '.':
+			l.readChar()
+			tok = token.Token{Type: token.OPTIONAL, Literal: "?."}
+		default:
+			tok = newToken(token.QUESTION, l.ch)
+		}
+	case '&':
+		if l.peekChar() != '&' {
+			return l.readIllegal()
+		}
+
+		l.readChar()
+		tok = token.Token{Type: token.AND, Literal: "&&"}
+	case '|':
+		switch l.peekChar() {
+		case '|':
+			l.readChar()
+			tok = token.Token{Type: token.OR, Literal: "||"}
+		case '>':
+			l.readChar()
+			tok = token.Token{Type: token.PIPE, Literal: "|>"}
+		default:
+			return l.readIllegal()
+		}
 	case '<':
-		tok = newToken(token.LT, l.ch)
+		tok = l.readOperator(token.LT, token.LT_EQ)
 	case '>':
-		tok = newToken(token.GT, l.ch)
+		tok = l.readOperator(token.GT, token.GT_EQ)
 	case '"':
-		tok.Type = token.STRING
-		tok.Literal = l.readString()
+		return l.readString(false)
+	case '`':
+		return l.readRawString()
 	case '[':
 		tok = newToken(token.LBRACKET, l.ch)
 	case ']':
@@ -114,11 +327,9 @@ func (l *Lexer) NextToken() token.Token {
 			tok.Type = token.LookupIdent(tok.Literal)
 			return tok
 		} else if isDigit(l.ch) {
-			tok.Type = token.INT
-			tok.Literal = l.readNumber()
-			return tok
+			return l.readNumber()
 		} else {
-			tok = newToken(token.ILLEGAL, l.ch)
+			return l.readIllegal()
 		}
 
 	}
@@ -127,6 +338,42 @@ func (l *Lexer) NextToken() token.Token {
 	return tok
 }
 
+// readComment reads a // line comment or a /* */ block comment, including its delimiters.
+// Block comments nest, so /* a /* b */ c */ is a single comment.
+// A block comment that is not closed before EOF is diagnosed and ends at EOF.
+func (l *Lexer) readComment() token.Token {
+	if l.peekChar() == '/' {
+		for l.ch != '\n' && l.ch != 0 {
+			l.readChar()
+		}
+
+		return token.Token{Type: token.COMMENT, Literal: l.text.String()}
+	}
+
+	l.readChar() // skip the '/' of the opening "/*"
+	l.readChar() // skip the '*'
+
+	depth := 1
+
+	for depth > 0 {
+		switch {
+		case l.ch == 0:
+			l.diagnose(l.start, "", "unterminated block comment", "add a closing `*/`")
+			return token.Token{Type: token.COMMENT, Literal: l.text.String()}
+		case l.ch == '/' && l.peekChar() == '*':
+			depth += 1
+			l.readChar()
+		case l.ch == '*' && l.peekChar() == '/':
+			depth -= 1
+			l.readChar()
+		}
+
+		l.readChar()
+	}
+
+	return token.Token{Type: token.COMMENT, Literal: l.text.String()}
+}
+
 // Skip the whitespace as we don't care about it.
 func (l *Lexer) skipWhitespace() {
 	for l.ch == ' ' || l.ch == '\t' || l.ch == '\n' || l.ch == '\r' {
@@ -135,51 +382,288 @@ func (l *Lexer) skipWhitespace() {
 }
 
 // just a helper function to create a new token
-func newToken(tokenType token.TokenType, ch byte) token.Token {
+func newToken(tokenType token.TokenType, ch rune) token.Token {
 	return token.Token{
 		Type:    tokenType,
 		Literal: string(ch),
 	}
 }
 
-func isLetter(ch byte) bool {
-	return 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || ch == '_'
+// isLetter reports whether ch can start an identifier: any Unicode letter or '_'.
+func isLetter(ch rune) bool {
+	return 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || ch == '_' ||
+		ch >= utf8.RuneSelf && unicode.IsLetter(ch)
+}
+
+// isIdentifierChar reports whether ch can continue an identifier, letters and digits (Δh2, λmax).
+func isIdentifierChar(ch rune) bool {
+	return isLetter(ch) || isDigit(ch) || ch >= utf8.RuneSelf && unicode.IsDigit(ch)
 }
 
 // read the identifier (atom, molecule, reaction, etc.)
 func (l *Lexer) readIdentifier() string {
-	position := l.position // position is the current position
 
-	for isLetter(l.ch) {
+	for isIdentifierChar(l.ch) {
 		l.readChar()
 	}
 
-	return l.input[position:l.position]
+	return l.text.String()
 }
 
-func isDigit(ch byte) bool {
+func isDigit(ch rune) bool {
 	return '0' <= ch && ch <= '9'
 }
 
-func (l *Lexer) readNumber() string {
-	position := l.position // position is the current position
+// readNumber reads a number literal:
+//   - decimal integers, with '_' allowed between digits: 42, 6_022_140_76
+//   - hexadecimal, octal and binary integers: 0xFF, 0o755, 0b1010
+//   - floats, with a fraction and/or an exponent: 1.008, 6.022e23, 1e-3
+//
+// The lexer only decides where the literal ends and whether it is an INT or a FLOAT,
+// malformed literals such as 1__0 or 0xZZ are reported by the parser.
+// A '.' that is not followed by a digit is left alone, so 1.foo is still INT followed by '.'.
+func (l *Lexer) readNumber() token.Token {
+
+	if l.ch == '0' && strings.ContainsRune("xXoObB", l.peekChar()) {
+		l.readChar()
+		l.readChar() // skip the base prefix
+
+		for isIdentifierChar(l.ch) { // read any letters too, so 0xFG is one bad literal
+			l.readChar()
+		}
+
+		return token.Token{Type: token.INT, Literal: l.text.String()}
+	}
+
+	tokenType := token.TokenType(token.INT)
+
+	l.readDigits()
+
+	if l.ch == '.' && isDigit(l.peekChar()) {
+		tokenType = token.FLOAT
+
+		l.readChar() // skip the '.'
+		l.readDigits()
+	}
+
+	if (l.ch == 'e' || l.ch == 'E') && (isDigit(l.peekChar()) || l.peekChar() == '+' || l.peekChar() == '-') {
+		tokenType = token.FLOAT
+
+		l.readChar() // skip the 'e'
+
+		if l.ch == '+' || l.ch == '-' {
+			l.readChar()
+		}
+
+		l.readDigits()
+	}
+
+	return token.Token{Type: tokenType, Literal: l.text.String()}
+}
+
+// readDigits reads decimal digits and the '_' separators between them.
+func (l *Lexer) readDigits() {
+	for isDigit(l.ch) || l.ch == '_' {
+		l.readChar()
+	}
+}
+
+// readString reads a double quoted string, decoding escape sequences:
+// \n, \t, \r, \0, \\, \", \', \$ and \u{XXXX} for any Unicode code point.
+// Unknown escapes are diagnosed and kept as written. A string that runs into
+// a newline or EOF is diagnosed as unterminated and ends there.
+//
+// When the string contains ${, reading stops there and the text so far is returned as
+// STRING_HEAD (or STRING_MIDDLE when resumed is set because we are continuing after
+// the } of an earlier interpolation). The closing quote then ends a STRING_TAIL.
+func (l *Lexer) readString(resumed bool) token.Token {
+	var out strings.Builder
+
+	stringType := token.TokenType(token.STRING)
+	if resumed {
+		stringType = token.STRING_TAIL
+	}
+
+	for {
+		l.readChar() // skip the opening quote, the closing } or the character just handled
+
+		switch l.ch {
+		case '"':
+			l.readChar() // skip the closing quote
+
+			return token.Token{Type: stringType, Literal: out.String()}
+
+		case '$':
+			if l.peekChar() != '{' {
+				out.WriteRune(l.ch)
+				continue
+			}
+
+			l.readChar()
+			l.readChar() // skip the ${
+
+			l.interpolations = append(l.interpolations, 0)
+
+			if resumed {
+				return token.Token{Type: token.STRING_MIDDLE, Literal: out.String()}
+			}
+
+			return token.Token{Type: token.STRING_HEAD, Literal: out.String()}
+
+		case '\n', 0:
+			l.diagnose(l.start, l.text.String(), "unterminated string", "add a closing `\"`")
+
+			return token.Token{Type: stringType, Literal: out.String()}
+
+		case '\\':
+			escapeStart := l.pos()
+			textLen := l.text.Len()
+
+			l.readChar()
+
+			if l.ch == '\n' || l.ch == 0 {
+				l.diagnose(l.start, l.text.String(), "unterminated string", "add a closing `\"`")
+
+				return token.Token{Type: stringType, Literal: out.String()}
+			}
+
+			ch, problem := l.readEscape()
+			if problem != nil {
+				escape := l.text.String()[textLen:] + string(l.ch)
+				l.diagnose(escapeStart, escape, problem.message, problem.suggestion)
+				out.WriteString(escape)
+				continue
+			}
+
+			out.WriteRune(ch)
+
+		default:
+			out.WriteRune(l.ch)
+		}
+	}
+}
+
+// escapeProblem says why an escape sequence could not be decoded.
+type escapeProblem struct {
+	message    string
+	suggestion string
+}
+
+var (
+	unknownEscape      = &escapeProblem{"unknown escape sequence", "use `\\\\` for a literal backslash"}
+	codePointTooBig    = &escapeProblem{"invalid code point", "code point must be at most 10FFFF"}
+	surrogateCodePoint = &escapeProblem{"invalid code point", "code points D800 to DFFF are reserved for UTF-16 surrogates"}
+)
+
+// readEscape decodes the escape sequence whose first character (after the backslash) is the current char.
+// It leaves the lexer on the last character of the sequence.
+func (l *Lexer) readEscape() (rune, *escapeProblem) {
+	switch l.ch {
+	case 'n':
+		return '\n', nil
+	case 't':
+		return '\t', nil
+	case 'r':
+		return '\r', nil
+	case '0':
+		return 0, nil
+	case '\\', '"', '\'', '$':
+		return l.ch, nil
+	case 'u':
+		if l.peekChar() != '{' {
+			return utf8.RuneError, unknownEscape
+		}
+
+		l.readChar()
+
+		var value rune
+
+		digits := 0
+
+		for isHexDigit(l.peekChar()) {
+			l.readChar()
+			value = value*16 + hexValue(l.ch)
+			digits += 1
+
+			if value > unicode.MaxRune {
+				value = unicode.MaxRune + 1 // keep it out of range without overflowing
+			}
+		}
+
+		if l.peekChar() != '}' {
+			return utf8.RuneError, unknownEscape
+		}
+
+		l.readChar()
+
+		switch {
+		case digits == 0:
+			return utf8.RuneError, unknownEscape
+		case value > unicode.MaxRune:
+			return utf8.RuneError, codePointTooBig
+		case !utf8.ValidRune(value):
+			return utf8.RuneError, surrogateCodePoint
+		}
+
+		return value, nil
+	default:
+		return utf8.RuneError, unknownEscape
+	}
+}
+
+// readIllegal consumes a character that cannot start a token and diagnoses it.
+func (l *Lexer) readIllegal() token.Token {
+	tok := newToken(token.ILLEGAL, l.ch)
+	l.readChar()
+	l.diagnose(l.start, tok.Literal, "unexpected character", suggestions[[]rune(tok.Literal)[0]])
+	return tok
+}
 
-	for isDigit(l.ch) {
+// readOperator returns withEquals, such as += or <=, when the current operator is followed by '='.
+func (l *Lexer) readOperator(plain, withEquals token.TokenType) token.Token {
+	if l.peekChar() == '=' {
+		ch := l.ch
 		l.readChar()
+		return token.Token{Type: withEquals, Literal: string(ch) + "="}
 	}
 
-	return l.input[position:l.position]
+	return newToken(plain, l.ch)
 }
 
-func (l *Lexer) readString() string {
-	position := l.position + 1 // position is the current position + 1 (because we want to skip the opening double quote)
+// readRawString reads a backtick quoted string. Nothing is escaped inside it and it can span lines.
+// A raw string still open at EOF is diagnosed and ends there.
+func (l *Lexer) readRawString() token.Token {
 	for {
 		l.readChar()
 
-		if l.ch == '"' || l.ch == 0 { // if we encounter a double quote or EOF
+		if l.ch == 0 {
+			l.diagnose(l.start, "", "unterminated raw string", "add a closing backtick")
+			break
+		}
+
+		if l.ch == '`' {
 			break
 		}
 	}
 
-	return l.input[position:l.position]
+	literal := strings.TrimPrefix(l.text.String(), "`") // drop the opening backtick
+
+	l.readChar() // skip the closing backtick
+
+	return token.Token{Type: token.STRING, Literal: literal}
+}
+
+func isHexDigit(ch rune) bool {
+	return isDigit(ch) || 'a' <= ch && ch <= 'f' || 'A' <= ch && ch <= 'F'
+}
+
+func hexValue(ch rune) rune {
+	switch {
+	case isDigit(ch):
+		return ch - '0'
+	case 'a' <= ch && ch <= 'f':
+		return ch - 'a' + 10
+	default:
+		return ch - 'A' + 10
+	}
 }
@@ -2,7 +2,10 @@ package lexer
 
 import (
 	"atom_script/token"
+	"errors"
+	"strings"
 	"testing"
+	"testing/iotest"
 )
 
 func TestNextToken(t *testing.T) {
@@ -16,7 +19,7 @@ func TestNextToken(t *testing.T) {
 
 	molecule result = add(five, ten);
 
-	!-/*5;
+	!-/ *5;
 	5 < 10 > 5;
 
 	if (5 < 10) {
@@ -141,3 +144,731 @@ func TestNextToken(t *testing.T) {
 		}
 	}
 }
+
+func TestTokenPositions(t *testing.T) {
+	input := "atom x = 10;\n  x + \"ab\";"
+
+	tests := []struct {
+		expectedType  token.TokenType
+		expectedStart token.Position
+		expectedEnd   token.Position
+	}{
+		{token.ATOM, token.Position{File: "test.atom", Line: 1, Column: 1, Offset: 0}, token.Position{File: "test.atom", Line: 1, Column: 5, Offset: 4}},
+		{token.IDENT, token.Position{File: "test.atom", Line: 1, Column: 6, Offset: 5}, token.Position{File: "test.atom", Line: 1, Column: 7, Offset: 6}},
+		{token.ASSIGN, token.Position{File: "test.atom", Line: 1, Column: 8, Offset: 7}, token.Position{File: "test.atom", Line: 1, Column: 9, Offset: 8}},
+		{token.INT, token.Position{File: "test.atom", Line: 1, Column: 10, Offset: 9}, token.Position{File: "test.atom", Line: 1, Column: 12, Offset: 11}},
+		{token.SEMICOLON, token.Position{File: "test.atom", Line: 1, Column: 12, Offset: 11}, token.Position{File: "test.atom", Line: 1, Column: 13, Offset: 12}},
+		{token.IDENT, token.Position{File: "test.atom", Line: 2, Column: 3, Offset: 15}, token.Position{File: "test.atom", Line: 2, Column: 4, Offset: 16}},
+		{token.PLUS, token.Position{File: "test.atom", Line: 2, Column: 5, Offset: 17}, token.Position{File: "test.atom", Line: 2, Column: 6, Offset: 18}},
+		{token.STRING, token.Position{File: "test.atom", Line: 2, Column: 7, Offset: 19}, token.Position{File: "test.atom", Line: 2, Column: 11, Offset: 23}},
+	}
+
+	l := NewFile("test.atom", input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("test[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Start != tt.expectedStart {
+			t.Errorf("test[%d] - start wrong. expected=%+v, got=%+v",
+				i, tt.expectedStart, tok.Start)
+		}
+
+		if tok.End != tt.expectedEnd {
+			t.Errorf("test[%d] - end wrong. expected=%+v, got=%+v",
+				i, tt.expectedEnd, tok.End)
+		}
+	}
+}
+
+func TestUnicodeInput(t *testing.T) {
+	input := `atom Δh2 = λ + "héllo"; é`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+		expectedColumn  int
+	}{
+		{token.ATOM, "atom", 1},
+		{token.IDENT, "Δh2", 6},
+		{token.ASSIGN, "=", 10},
+		{token.IDENT, "λ", 12},
+		{token.PLUS, "+", 14},
+		{token.STRING, "héllo", 16},
+		{token.SEMICOLON, ";", 23},
+		{token.IDENT, "é", 25},
+		{token.EOF, "", 26},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("test[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("test[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+
+		if tok.Start.Column != tt.expectedColumn {
+			t.Errorf("test[%d] - column wrong. expected=%d, got=%d",
+				i, tt.expectedColumn, tok.Start.Column)
+		}
+	}
+}
+
+func TestComments(t *testing.T) {
+	input := `// leading comment
+atom a = 10 / 2; // trailing comment
+/* block /* nested */ still comment */ a
+/* unterminated`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.ATOM, "atom"},
+		{token.IDENT, "a"},
+		{token.ASSIGN, "="},
+		{token.INT, "10"},
+		{token.SLASH, "/"},
+		{token.INT, "2"},
+		{token.SEMICOLON, ";"},
+		{token.IDENT, "a"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("test[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("test[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestScanComments(t *testing.T) {
+	input := `// doc
+atom a = 1; /* a /* b */ */`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.COMMENT, "// doc"},
+		{token.ATOM, "atom"},
+		{token.IDENT, "a"},
+		{token.ASSIGN, "="},
+		{token.INT, "1"},
+		{token.SEMICOLON, ";"},
+		{token.COMMENT, "/* a /* b */ */"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+	l.SetMode(ScanComments)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("test[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("test[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestStringEscapes(t *testing.T) {
+	tests := []struct {
+		input              string
+		expectedLiteral    string
+		expectedDiagnostic string
+	}{
+		{`"plain"`, "plain", ""},
+		{`"say \"hi\""`, `say "hi"`, ""},
+		{`"a\nb\tc\rd"`, "a\nb\tc\rd", ""},
+		{`"back\\slash"`, `back\slash`, ""},
+		{`"it\'s"`, "it's", ""},
+		{`"nul\0"`, "nul\x00", ""},
+		{`"\u{394}h = \u{1F525}"`, "Δh = 🔥", ""},
+		{"`raw \\n \"text\"`", `raw \n "text"`, ""},
+		{"`line one\nline two`", "line one\nline two", ""},
+		{`"bad \q escape"`, `bad \q escape`, "1:6: unknown escape sequence `\\q` (use `\\\\` for a literal backslash)"},
+		{`"\u{110000}"`, `\u{110000}`, "1:2: invalid code point `\\u{110000}` (code point must be at most 10FFFF)"},
+		{`"\u{FFFFFFFFF}"`, `\u{FFFFFFFFF}`, "1:2: invalid code point `\\u{FFFFFFFFF}` (code point must be at most 10FFFF)"},
+		{`"\u{D800}"`, `\u{D800}`, "1:2: invalid code point `\\u{D800}` (code points D800 to DFFF are reserved for UTF-16 surrogates)"},
+		{`"\u{}"`, `\u{}`, "1:2: unknown escape sequence `\\u{}` (use `\\\\` for a literal backslash)"},
+		{`"unterminated`, "unterminated", "1:1: unterminated string `\"unterminated` (add a closing `\"`)"},
+		{"\"broken\nline\"", "broken", "1:1: unterminated string `\"broken` (add a closing `\"`)"},
+		{"`never closed", "never closed", "1:1: unterminated raw string (add a closing backtick)"},
+	}
+
+	for i, tt := range tests {
+		l := New(tt.input)
+		tok := l.NextToken()
+
+		if tok.Type != token.STRING {
+			t.Fatalf("test[%d] - tokentype wrong. expected=%q, got=%q",
+				i, token.STRING, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("test[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+
+		diagnostics := l.Diagnostics()
+
+		if tt.expectedDiagnostic == "" {
+			if len(diagnostics) != 0 {
+				t.Errorf("test[%d] - unexpected diagnostics: %v", i, diagnostics)
+			}
+			continue
+		}
+
+		if len(diagnostics) != 1 {
+			t.Fatalf("test[%d] - expected 1 diagnostic, got=%v", i, diagnostics)
+		}
+
+		if diagnostics[0].String() != tt.expectedDiagnostic {
+			t.Errorf("test[%d] - diagnostic wrong. expected=%q, got=%q",
+				i, tt.expectedDiagnostic, diagnostics[0].String())
+		}
+	}
+}
+
+func TestStringInterpolation(t *testing.T) {
+	input := `"mass of ${el["name"]} is ${ {"m": 1}["m"] }\${not}"`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.STRING_HEAD, "mass of "},
+		{token.IDENT, "el"},
+		{token.LBRACKET, "["},
+		{token.STRING, "name"},
+		{token.RBRACKET, "]"},
+		{token.STRING_MIDDLE, " is "},
+		{token.LBRACE, "{"},
+		{token.STRING, "m"},
+		{token.COLON, ":"},
+		{token.INT, "1"},
+		{token.RBRACE, "}"},
+		{token.LBRACKET, "["},
+		{token.STRING, "m"},
+		{token.RBRACKET, "]"},
+		{token.STRING_TAIL, "${not}"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("test[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("test[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestFloatNumbers(t *testing.T) {
+	input := `1.008 42 0.5 3.`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.FLOAT, "1.008"},
+		{token.INT, "42"},
+		{token.FLOAT, "0.5"},
+		{token.INT, "3"},
+		{token.DOT, "."},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("test[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("test[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestNumberLiteralForms(t *testing.T) {
+	input := `0xFF 0o755 0b1010 6_022_140_76 6.022e23 1E-3 2e+5 1_000.5 0xFG 3e x 010 08`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.INT, "0xFF"},
+		{token.INT, "0o755"},
+		{token.INT, "0b1010"},
+		{token.INT, "6_022_140_76"},
+		{token.FLOAT, "6.022e23"},
+		{token.FLOAT, "1E-3"},
+		{token.FLOAT, "2e+5"},
+		{token.FLOAT, "1_000.5"},
+		{token.INT, "0xFG"},
+		{token.INT, "3"},
+		{token.IDENT, "e"},
+		{token.IDENT, "x"},
+		{token.INT, "010"},
+		{token.INT, "08"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("test[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("test[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestNewReader(t *testing.T) {
+	input := "atom Δ = \"λ ${x}\";\n// comment\nΔ * 1.5"
+
+	expected := New(input)
+
+	// OneByteReader splits every multi-byte rune across reads.
+	l := NewReader("stream.atom", iotest.OneByteReader(strings.NewReader(input)))
+
+	for i := 0; ; i++ {
+		want := expected.NextToken()
+		tok := l.NextToken()
+
+		if tok.Type != want.Type || tok.Literal != want.Literal {
+			t.Fatalf("test[%d] - token wrong. expected=%q %q, got=%q %q",
+				i, want.Type, want.Literal, tok.Type, tok.Literal)
+		}
+
+		want.Start.File = "stream.atom"
+		want.End.File = "stream.atom"
+
+		if tok.Start != want.Start || tok.End != want.End {
+			t.Fatalf("test[%d] - span wrong. expected=%+v-%+v, got=%+v-%+v",
+				i, want.Start, want.End, tok.Start, tok.End)
+		}
+
+		if tok.Type == token.EOF {
+			break
+		}
+	}
+
+	if l.Err() != nil {
+		t.Errorf("unexpected read error: %v", l.Err())
+	}
+}
+
+func TestNewReaderError(t *testing.T) {
+	readErr := errors.New("disk on fire")
+
+	l := NewReader("", iotest.ErrReader(readErr))
+
+	if tok := l.NextToken(); tok.Type != token.EOF {
+		t.Fatalf("tokentype wrong. expected=%q, got=%q", token.EOF, tok.Type)
+	}
+
+	if l.Err() != readErr {
+		t.Errorf("l.Err() wrong. expected=%v, got=%v", readErr, l.Err())
+	}
+}
+
+func TestDiagnostics(t *testing.T) {
+	input := `atom a = 1 # 2;
+a === 1;
+a !== 2;
+a × 2;
+/* never closed`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.ATOM, "atom"},
+		{token.IDENT, "a"},
+		{token.ASSIGN, "="},
+		{token.INT, "1"},
+		{token.INT, "2"},
+		{token.SEMICOLON, ";"},
+		{token.IDENT, "a"},
+		{token.EQ, "=="},
+		{token.INT, "1"},
+		{token.SEMICOLON, ";"},
+		{token.IDENT, "a"},
+		{token.NOT_EQ, "!="},
+		{token.INT, "2"},
+		{token.SEMICOLON, ";"},
+		{token.IDENT, "a"},
+		{token.INT, "2"},
+		{token.SEMICOLON, ";"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("test[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("test[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+
+	expected := []string{
+		"1:12: unexpected character `#` (comments start with `//`)",
+		"2:3: unexpected operator `===` (did you mean `==`?)",
+		"3:3: unexpected operator `!==` (did you mean `!=`?)",
+		"4:3: unexpected character `×` (did you mean `*`?)",
+		"5:1: unterminated block comment (add a closing `*/`)",
+	}
+
+	diagnostics := l.Diagnostics()
+
+	if len(diagnostics) != len(expected) {
+		t.Fatalf("wrong number of diagnostics. expected=%d, got=%v", len(expected), diagnostics)
+	}
+
+	for i, want := range expected {
+		if diagnostics[i].String() != want {
+			t.Errorf("diagnostic[%d] wrong. expected=%q, got=%q", i, want, diagnostics[i].String())
+		}
+	}
+}
+
+func TestLoopKeywords(t *testing.T) {
+	input := `while for (k, v in xs) inside`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.WHILE, "while"},
+		{token.FOR, "for"},
+		{token.LPAREN, "("},
+		{token.IDENT, "k"},
+		{token.COMMA, ","},
+		{token.IDENT, "v"},
+		{token.IN, "in"},
+		{token.IDENT, "xs"},
+		{token.RPAREN, ")"},
+		{token.IDENT, "inside"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("test[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("test[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestAssignOperators(t *testing.T) {
+	input := `x = 1; x += 2; x -= 3; x *= 4; x /= 5; x %= 6; x+1`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.IDENT, "x"}, {token.ASSIGN, "="}, {token.INT, "1"}, {token.SEMICOLON, ";"},
+		{token.IDENT, "x"}, {token.PLUS_ASSIGN, "+="}, {token.INT, "2"}, {token.SEMICOLON, ";"},
+		{token.IDENT, "x"}, {token.MINUS_ASSIGN, "-="}, {token.INT, "3"}, {token.SEMICOLON, ";"},
+		{token.IDENT, "x"}, {token.ASTERISK_ASSIGN, "*="}, {token.INT, "4"}, {token.SEMICOLON, ";"},
+		{token.IDENT, "x"}, {token.SLASH_ASSIGN, "/="}, {token.INT, "5"}, {token.SEMICOLON, ";"},
+		{token.IDENT, "x"}, {token.PERCENT_ASSIGN, "%="}, {token.INT, "6"}, {token.SEMICOLON, ";"},
+		{token.IDENT, "x"}, {token.PLUS, "+"}, {token.INT, "1"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("test[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("test[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestLogicalAndComparisonOperators(t *testing.T) {
+	input := `a && b || c <= d >= e % f < g > h & i`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.IDENT, "a"}, {token.AND, "&&"}, {token.IDENT, "b"}, {token.OR, "||"},
+		{token.IDENT, "c"}, {token.LT_EQ, "<="}, {token.IDENT, "d"}, {token.GT_EQ, ">="},
+		{token.IDENT, "e"}, {token.PERCENT, "%"}, {token.IDENT, "f"}, {token.LT, "<"},
+		{token.IDENT, "g"}, {token.GT, ">"}, {token.IDENT, "h"}, {token.IDENT, "i"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("test[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("test[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+
+	diagnostics := l.Diagnostics()
+	if len(diagnostics) != 1 || diagnostics[0].Suggestion != "did you mean `&&`?" {
+		t.Errorf("expected a single diagnostic for the lone &. got=%v", diagnostics)
+	}
+}
+
+func TestNullishAndOptionalTokens(t *testing.T) {
+	input := `null a ?? b a?.b a?.[0] f?.(x)`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.NULL, "null"},
+		{token.IDENT, "a"}, {token.NULLISH, "??"}, {token.IDENT, "b"},
+		{token.IDENT, "a"}, {token.OPTIONAL, "?."}, {token.IDENT, "b"},
+		{token.IDENT, "a"}, {token.OPTIONAL, "?."}, {token.LBRACKET, "["}, {token.INT, "0"}, {token.RBRACKET, "]"},
+		{token.IDENT, "f"}, {token.OPTIONAL, "?."}, {token.LPAREN, "("}, {token.IDENT, "x"}, {token.RPAREN, ")"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("test[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("test[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestQuestionAndPipeTokens(t *testing.T) {
+	input := `a ? b : c |> f || g`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.IDENT, "a"}, {token.QUESTION, "?"}, {token.IDENT, "b"}, {token.COLON, ":"},
+		{token.IDENT, "c"}, {token.PIPE, "|>"}, {token.IDENT, "f"}, {token.OR, "||"},
+		{token.IDENT, "g"}, {token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("test[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("test[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestArrowToken(t *testing.T) {
+	input := `(x) => x == 1 = >`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.LPAREN, "("}, {token.IDENT, "x"}, {token.RPAREN, ")"}, {token.ARROW, "=>"},
+		{token.IDENT, "x"}, {token.EQ, "=="}, {token.INT, "1"}, {token.ASSIGN, "="},
+		{token.GT, ">"}, {token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("test[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("test[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestEllipsis(t *testing.T) {
+	l := New(`...rest a.b .. x`)
+
+	expected := []token.TokenType{token.ELLIPSIS, token.IDENT, token.IDENT, token.DOT, token.IDENT, token.IDENT, token.EOF}
+
+	for i, tt := range expected {
+		tok := l.NextToken()
+
+		if tok.Type != tt {
+			t.Fatalf("test[%d] - tokentype wrong. expected=%q, got=%q", i, tt, tok.Type)
+		}
+	}
+
+	diagnostics := l.Diagnostics()
+	if len(diagnostics) != 1 || diagnostics[0].Text != ".." {
+		t.Errorf("expected one diagnostic for `..`. got=%v", diagnostics)
+	}
+}
+
+func TestMatchKeyword(t *testing.T) {
+	input := `match x { _ => matches }`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.MATCH, "match"},
+		{token.IDENT, "x"},
+		{token.LBRACE, "{"},
+		{token.IDENT, "_"},
+		{token.ARROW, "=>"},
+		{token.IDENT, "matches"},
+		{token.RBRACE, "}"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("test[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("test[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestExceptionKeywords(t *testing.T) {
+	input := `try catch finally throw trying`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.TRY, "try"},
+		{token.CATCH, "catch"},
+		{token.FINALLY, "finally"},
+		{token.THROW, "throw"},
+		{token.IDENT, "trying"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("test[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("test[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
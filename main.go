@@ -40,32 +40,43 @@ func main() {
 }
 
 func evalFile(file string) {
-	bytes, err := os.ReadFile(file)
+	f, err := os.Open(file)
 
 	if err != nil {
 		fmt.Println("Error reading file, please check if the file exists")
 		return
 	}
 
-	l := lexer.New(string(bytes))
+	defer f.Close()
+
+	l := lexer.NewReader(file, f)
 
 	p := parser.New(l)
 
 	program := p.ParseProgram()
 
+	if l.Err() != nil {
+		fmt.Println("Error reading file:", l.Err())
+		return
+	}
+
 	if len(p.Errors()) != 0 {
 		for _, err := range p.Errors() {
 			fmt.Println(err)
 		}
 	}
 
+	for _, warning := range p.Warnings() {
+		fmt.Println(warning)
+	}
+
 	env := object.NewEnvironment()
 
 	for _, stmt := range program.Statements {
 		evaluated := evaluator.Eval(stmt, env)
 
 		if evaluated != nil {
-			fmt.Println(evaluated.Inspect())
+			fmt.Println(object.Report(evaluated))
 		}
 	}
 }
@@ -1,5 +1,13 @@
 package object
 
+// BindingKind tells how a name was bound: atom bindings can be reassigned, molecule bindings cannot.
+type BindingKind int
+
+const (
+	ATOM_BINDING BindingKind = iota
+	MOLECULE_BINDING
+)
+
 func NewEnclosedEnvironment(outer *Environment) *Environment {
 	env := NewEnvironment()
 	env.outer = outer
@@ -8,12 +16,31 @@ func NewEnclosedEnvironment(outer *Environment) *Environment {
 
 func NewEnvironment() *Environment {
 	s := make(map[string]Object)
-	return &Environment{store: s, outer: nil}
+	k := make(map[string]BindingKind)
+	return &Environment{store: s, kinds: k, outer: nil}
 }
 
 type Environment struct {
 	store map[string]Object
+	kinds map[string]BindingKind
 	outer *Environment
+	frame *Frame // set on the environment a reaction call runs its body in
+}
+
+// EnterFrame marks e as the environment of the reaction call running in frame.
+func (e *Environment) EnterFrame(frame *Frame) {
+	e.frame = frame
+}
+
+// Frame returns the reaction call that code running in e belongs to, or nil at the top level.
+func (e *Environment) Frame() *Frame {
+	for env := e; env != nil; env = env.outer {
+		if env.frame != nil {
+			return env.frame
+		}
+	}
+
+	return nil
 }
 
 func (e *Environment) Get(name string) (Object, bool) {
@@ -26,7 +53,54 @@ func (e *Environment) Get(name string) (Object, bool) {
 	return obj, ok
 }
 
+// Set binds name in this environment as a reassignable (atom) binding.
 func (e *Environment) Set(name string, val Object) Object {
 	e.store[name] = val
+	e.kinds[name] = ATOM_BINDING
 	return val
 }
+
+// Define binds name in this environment with the given kind.
+// It reports false, and leaves the environment alone, when name is already a molecule here.
+// A molecule in an outer environment can still be shadowed.
+func (e *Environment) Define(name string, val Object, kind BindingKind) bool {
+	if _, ok := e.store[name]; ok && e.kinds[name] == MOLECULE_BINDING {
+		return false
+	}
+
+	e.store[name] = val
+	e.kinds[name] = kind
+	return true
+}
+
+// Kind returns the kind of the nearest binding of name.
+func (e *Environment) Kind(name string) (BindingKind, bool) {
+	if _, ok := e.store[name]; ok {
+		return e.kinds[name], true
+	}
+
+	if e.outer != nil {
+		return e.outer.Kind(name)
+	}
+
+	return ATOM_BINDING, false
+}
+
+// Assign replaces the value of an existing binding, in the nearest environment that defines name.
+// It reports false when no environment in the chain does, or when that binding is a molecule.
+func (e *Environment) Assign(name string, val Object) bool {
+	if _, ok := e.store[name]; ok {
+		if e.kinds[name] == MOLECULE_BINDING {
+			return false
+		}
+
+		e.store[name] = val
+		return true
+	}
+
+	if e.outer != nil {
+		return e.outer.Assign(name, val)
+	}
+
+	return false
+}
@@ -2,9 +2,13 @@ package object
 
 import (
 	"atom_script/ast"
+	"atom_script/token"
 	"bytes"
 	"fmt"
 	"hash/fnv"
+	"math"
+	"math/big"
+	"strconv"
 	"strings"
 )
 
@@ -12,6 +16,8 @@ type ObjectType string
 
 const (
 	INTEGER_OBJ       = "INTEGER"
+	BIGINT_OBJ        = "BIGINT"
+	FLOAT_OBJ         = "FLOAT"
 	BOOLEAN_OBJ       = "BOOLEAN"
 	NULL_OBJ          = "NULL"
 	PRODUCE_VALUE_OBJ = "PRODUCE_VALUE_OBJ"
@@ -21,6 +27,21 @@ const (
 	BUILTIN_OBJ       = "BUILTIN"
 	ARRAY_OBJ         = "ARRAY"
 	HASH_OBJ          = "HASH"
+	RANGE_OBJ         = "RANGE"
+	BREAK_OBJ         = "BREAK"
+	CONTINUE_OBJ      = "CONTINUE"
+	EXCEPTION_OBJ     = "EXCEPTION"
+)
+
+// Kinds of errors, which scripts read as e.kind. A script can also throw errors of its own kinds.
+const (
+	GENERIC_ERROR    = "Error"
+	TYPE_ERROR       = "TypeError"       // a value of the wrong type
+	ARGUMENT_ERROR   = "ArgumentError"   // a call with the wrong arguments
+	NAME_ERROR       = "NameError"       // an unknown name, or a molecule written to
+	INDEX_ERROR      = "IndexError"      // an index or key that is not there
+	VALUE_ERROR      = "ValueError"      // a value of the right type that cannot be used
+	ARITHMETIC_ERROR = "ArithmeticError" // division by zero
 )
 
 type Object interface {
@@ -49,6 +70,60 @@ func (i *Integer) HashKey() HashKey {
 	return HashKey{Type: i.Type(), Value: uint64(i.Value)}
 }
 
+// BigInt is an integer too large for an Integer. The evaluator only creates one
+// when a value does not fit in an int64, so equal numbers never have both forms.
+type BigInt struct {
+	Value *big.Int
+}
+
+func (b *BigInt) Type() ObjectType { return BIGINT_OBJ }
+func (b *BigInt) Inspect() string  { return b.Value.String() }
+
+func (b *BigInt) HashKey() HashKey {
+	h := fnv.New64a()
+
+	if b.Value.Sign() < 0 {
+		h.Write([]byte{'-'})
+	}
+
+	h.Write(b.Value.Bytes())
+
+	return HashKey{Type: b.Type(), Value: h.Sum64()}
+}
+
+// Float
+type Float struct {
+	Value float64
+}
+
+func (f *Float) Type() ObjectType { return FLOAT_OBJ }
+
+// Inspect always shows a float as one, so 2.0 does not print like the integer 2.
+func (f *Float) Inspect() string {
+	str := strconv.FormatFloat(f.Value, 'g', -1, 64)
+
+	if !strings.ContainsAny(str, ".eIN") { // e for exponents, I and N for Inf and NaN
+		str += ".0"
+	}
+
+	return str
+}
+
+// HashKey gives a float with an integer value the key of that integer, since the two are ==.
+func (f *Float) HashKey() HashKey {
+	if f.Value == math.Trunc(f.Value) && !math.IsInf(f.Value, 0) {
+		if f.Value >= math.MinInt64 && f.Value < math.MaxInt64 {
+			return (&Integer{Value: int64(f.Value)}).HashKey()
+		}
+
+		value, _ := big.NewFloat(f.Value).Int(nil)
+
+		return (&BigInt{Value: value}).HashKey()
+	}
+
+	return HashKey{Type: f.Type(), Value: math.Float64bits(f.Value)}
+}
+
 // Boolean
 type Boolean struct {
 	Value bool
@@ -82,15 +157,120 @@ type ProduceValue struct {
 func (p *ProduceValue) Type() ObjectType { return PRODUCE_VALUE_OBJ }
 func (p *ProduceValue) Inspect() string  { return p.Value.Inspect() }
 
+// Break and Continue travel out of blocks like ProduceValue until they reach the loop named by Label,
+// or the innermost loop when Label is empty.
+type Break struct {
+	Label string
+}
+
+func (b *Break) Type() ObjectType { return BREAK_OBJ }
+func (b *Break) Inspect() string  { return "break" }
+
+type Continue struct {
+	Label string
+}
+
+func (c *Continue) Type() ObjectType { return CONTINUE_OBJ }
+func (c *Continue) Inspect() string  { return "continue" }
+
+// Error is an error on its way up to the nearest catch. Once caught it is handed to the script as an Exception.
 type Error struct {
 	Message string
+	Kind    string         // one of the kinds above, or the kind given to error()
+	Payload Object         // the value that was thrown, nil for errors raised by the interpreter
+	Stack   []*Frame       // the reaction calls that were running when it happened, innermost first
+	Pos     token.Position // where in the source the error happened
 }
 
 func (e *Error) Type() ObjectType { return ERROR_OBJ }
-func (e *Error) Inspect() string  { return "ERROR: " + e.Message }
+
+func (e *Error) Inspect() string {
+	message := e.Message
+	if e.Kind != "" && e.Kind != GENERIC_ERROR {
+		message = e.Kind + ": " + message
+	}
+
+	if e.Pos.IsValid() {
+		return "ERROR: " + e.Pos.String() + ": " + message
+	}
+
+	return "ERROR: " + message
+}
+
+// Traceback is Inspect followed by one line per frame of the stack, each with the position
+// the frame had reached: where the error happened for the innermost, the call to the next one in for the others.
+func (e *Error) Traceback() string {
+	var out bytes.Buffer
+
+	out.WriteString(e.Inspect())
+
+	if len(e.Stack) == 0 {
+		return out.String()
+	}
+
+	pos := e.Pos
+
+	for _, frame := range e.Stack {
+		writeFrameLine(&out, frame.Name, pos)
+		pos = frame.Site
+	}
+
+	writeFrameLine(&out, "<top level>", pos)
+
+	return out.String()
+}
+
+func writeFrameLine(out *bytes.Buffer, name string, pos token.Position) {
+	out.WriteString("\n    at " + name)
+
+	if pos.IsValid() {
+		out.WriteString(" (" + pos.String() + ")")
+	}
+}
+
+// Report is what the CLI, the REPL and the API print for the value of a statement:
+// its Inspect, or the full traceback for an error.
+func Report(obj Object) string {
+	if err, ok := obj.(*Error); ok {
+		return err.Traceback()
+	}
+
+	return obj.Inspect()
+}
+
+// Frame is one reaction call on the script's call stack.
+type Frame struct {
+	Name   string         // the reaction being run
+	Site   token.Position // where it was called
+	Caller *Frame         // the frame it was called from, nil for a call made at the top level
+}
+
+// Stack lists f and the frames it was called from, innermost first.
+func (f *Frame) Stack() []*Frame {
+	frames := []*Frame{}
+
+	for frame := f; frame != nil; frame = frame.Caller {
+		frames = append(frames, frame)
+	}
+
+	return frames
+}
+
+// Exception is a caught error as a script sees it: an ordinary value with the fields
+// message, kind, payload, stack, position and traceback, which can be thrown again.
+type Exception struct {
+	Error *Error
+}
+
+func (e *Exception) Type() ObjectType { return EXCEPTION_OBJ }
+
+func (e *Exception) Inspect() string {
+	return e.Error.Kind + ": " + e.Error.Message
+}
 
 type Reaction struct {
-	Parameters []*ast.Identifier
+	Name       string // the name it was declared or first bound with, "" for an anonymous reaction
+	Parameters []*ast.Parameter
 	Body       *ast.BlockStatement
 	Env        *Environment
 }
@@ -157,6 +337,38 @@ func (ao *Array) Inspect() string {
 	return out.String()
 }
 
+// Range is the lazy sequence of integers from Start up to (not including) End, counting by Step.
+type Range struct {
+	Start int64
+	End   int64
+	Step  int64
+}
+
+func (r *Range) Type() ObjectType { return RANGE_OBJ }
+
+func (r *Range) Inspect() string {
+	return fmt.Sprintf("range(%d, %d, %d)", r.Start, r.End, r.Step)
+}
+
+// Len returns how many integers the range yields. The `range` builtin makes sure the count fits.
+func (r *Range) Len() int64 {
+	return int64(r.Count())
+}
+
+// Count returns how many integers the range yields, worked out in unsigned arithmetic
+// since the distance between the bounds can be larger than the biggest int64.
+func (r *Range) Count() uint64 {
+	if r.Step > 0 && r.Start < r.End {
+		return (uint64(r.End)-uint64(r.Start)-1)/uint64(r.Step) + 1
+	}
+
+	if r.Step < 0 && r.Start > r.End {
+		return (uint64(r.Start)-uint64(r.End)-1)/uint64(-r.Step) + 1
+	}
+
+	return 0
+}
+
 type HashPair struct {
 	Key   Object
 	Value Object
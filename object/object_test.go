@@ -1,6 +1,9 @@
 package object
 
 import (
+	"atom_script/token"
+	"math"
+	"math/big"
 	"testing"
 )
 
@@ -22,3 +25,157 @@ func TestStringHashKey(t *testing.T) {
 		t.Errorf("strings with different content have same hash keys")
 	}
 }
+
+func TestFloatInspectAndHashKey(t *testing.T) {
+	tests := []struct {
+		value    float64
+		expected string
+	}{
+		{1.008, "1.008"},
+		{2, "2.0"},
+		{-0.5, "-0.5"},
+		{6.022e23, "6.022e+23"},
+	}
+
+	for _, tt := range tests {
+		f := &Float{Value: tt.value}
+		if f.Inspect() != tt.expected {
+			t.Errorf("Inspect() wrong. want=%q, got=%q", tt.expected, f.Inspect())
+		}
+	}
+
+	if (&Float{Value: 1.5}).HashKey() != (&Float{Value: 1.5}).HashKey() {
+		t.Errorf("floats with same value have different hash keys")
+	}
+
+	if (&Float{Value: 1.5}).HashKey() == (&Float{Value: 2.5}).HashKey() {
+		t.Errorf("floats with different values have same hash keys")
+	}
+
+	if (&Float{Value: 1}).HashKey() != (&Integer{Value: 1}).HashKey() {
+		t.Errorf("float 1.0 and integer 1 have different hash keys")
+	}
+
+	if (&Float{Value: math.Copysign(0, -1)}).HashKey() != (&Integer{Value: 0}).HashKey() {
+		t.Errorf("float -0.0 and integer 0 have different hash keys")
+	}
+
+	value, _ := new(big.Int).SetString("92233720368547758080", 10)
+	if (&Float{Value: 92233720368547758080}).HashKey() != (&BigInt{Value: value}).HashKey() {
+		t.Errorf("float 9.223372036854775808e+19 and the same BigInt have different hash keys")
+	}
+}
+
+func TestBigIntHashKey(t *testing.T) {
+	a, _ := new(big.Int).SetString("602214076000000000000000", 10)
+	b, _ := new(big.Int).SetString("602214076000000000000000", 10)
+	c := new(big.Int).Neg(a)
+
+	if (&BigInt{Value: a}).HashKey() != (&BigInt{Value: b}).HashKey() {
+		t.Errorf("big integers with same value have different hash keys")
+	}
+
+	if (&BigInt{Value: a}).HashKey() == (&BigInt{Value: c}).HashKey() {
+		t.Errorf("big integers with different sign have same hash keys")
+	}
+}
+
+func TestEnvironmentAssign(t *testing.T) {
+	outer := NewEnvironment()
+	outer.Set("x", &Integer{Value: 1})
+
+	inner := NewEnclosedEnvironment(outer)
+
+	if !inner.Assign("x", &Integer{Value: 2}) {
+		t.Fatalf("Assign did not find x in the outer environment")
+	}
+
+	if val, _ := outer.Get("x"); val.(*Integer).Value != 2 {
+		t.Errorf("outer x was not updated. got=%s", val.Inspect())
+	}
+
+	if inner.Assign("y", &Integer{Value: 3}) {
+		t.Errorf("Assign created the undeclared name y")
+	}
+
+	if _, ok := inner.Get("y"); ok {
+		t.Errorf("y should not be bound")
+	}
+}
+
+func TestEnvironmentMolecules(t *testing.T) {
+	outer := NewEnvironment()
+
+	if !outer.Define("c", &Integer{Value: 1}, MOLECULE_BINDING) {
+		t.Fatalf("Define refused a new molecule")
+	}
+
+	if outer.Define("c", &Integer{Value: 2}, ATOM_BINDING) {
+		t.Errorf("Define redeclared a molecule")
+	}
+
+	if outer.Assign("c", &Integer{Value: 3}) {
+		t.Errorf("Assign changed a molecule")
+	}
+
+	if val, _ := outer.Get("c"); val.(*Integer).Value != 1 {
+		t.Errorf("molecule c changed. got=%s", val.Inspect())
+	}
+
+	inner := NewEnclosedEnvironment(outer)
+
+	if !inner.Define("c", &Integer{Value: 4}, ATOM_BINDING) {
+		t.Errorf("Define refused to shadow an outer molecule")
+	}
+
+	if kind, _ := inner.Kind("c"); kind != ATOM_BINDING {
+		t.Errorf("shadowing binding has wrong kind. got=%d", kind)
+	}
+}
+
+func TestErrorInspect(t *testing.T) {
+	tests := []struct {
+		obj      Object
+		expected string
+	}{
+		{&Error{Message: "boom", Kind: GENERIC_ERROR}, "ERROR: boom"},
+		{&Error{Message: "type mismatch: INTEGER + STRING", Kind: TYPE_ERROR}, "ERROR: TypeError: type mismatch: INTEGER + STRING"},
+		{&Exception{Error: &Error{Message: "no element Xx", Kind: "LookupError"}}, "LookupError: no element Xx"},
+		{&Exception{Error: &Error{Message: "boom", Kind: GENERIC_ERROR}}, "Error: boom"},
+	}
+
+	for _, tt := range tests {
+		if tt.obj.Inspect() != tt.expected {
+			t.Errorf("wrong Inspect. want=%q, got=%q", tt.expected, tt.obj.Inspect())
+		}
+	}
+}
+
+func TestErrorTraceback(t *testing.T) {
+	pos := func(line, column int) token.Position {
+		return token.Position{File: "f.atom", Line: line, Column: column}
+	}
+
+	outer := &Frame{Name: "outer", Site: pos(7, 6)}
+	inner := &Frame{Name: "inner", Site: pos(5, 8), Caller: outer}
+
+	tests := []struct {
+		err      *Error
+		expected string
+	}{
+		{&Error{Message: "boom", Kind: GENERIC_ERROR, Pos: pos(1, 1)}, "ERROR: f.atom:1:1: boom"},
+		{
+			&Error{Message: "type mismatch: INTEGER + STRING", Kind: TYPE_ERROR, Pos: pos(2, 5), Stack: inner.Stack()},
+			"ERROR: f.atom:2:5: TypeError: type mismatch: INTEGER + STRING\n" +
+				"    at inner (f.atom:2:5)\n" +
+				"    at outer (f.atom:5:8)\n" +
+				"    at <top level> (f.atom:7:6)",
+		},
+	}
+
+	for _, tt := range tests {
+		if tt.err.Traceback() != tt.expected {
+			t.Errorf("wrong Traceback. want=%q, got=%q", tt.expected, tt.err.Traceback())
+		}
+	}
+}
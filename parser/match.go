@@ -0,0 +1,148 @@
+package parser
+
+import (
+	"atom_script/ast"
+	"atom_script/token"
+	"strings"
+)
+
+// parseMatchExpression parses match subject { pattern if guard => body, ... }.
+// The commas between arms are optional, and a body is either one expression or a { block }.
+func (p *Parser) parseMatchExpression() ast.Expression {
+	exp := &ast.MatchExpression{Token: p.curToken}
+
+	p.nextToken()
+
+	exp.Subject = p.parseExpression(LOWEST)
+
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	for !p.peekTokenIs(token.RBRACE) && !p.peekTokenIs(token.EOF) {
+		p.nextToken()
+
+		arm := p.parseMatchArm()
+		if arm == nil {
+			return nil
+		}
+
+		exp.Arms = append(exp.Arms, arm)
+
+		if p.peekTokenIs(token.COMMA) {
+			p.nextToken()
+		}
+	}
+
+	if !p.expectPeek(token.RBRACE) {
+		return nil
+	}
+
+	p.checkExhaustive(exp)
+
+	return exp
+}
+
+func (p *Parser) parseMatchArm() *ast.MatchArm {
+	arm := &ast.MatchArm{}
+
+	matching := p.matching
+	p.matching = true
+	arm.Pattern = p.parsePattern()
+	p.matching = matching
+
+	if arm.Pattern == nil {
+		return nil
+	}
+
+	// the names bound by the pattern are visible in the guard and the body only
+	exitScope := p.enterScope(p.distinctBindings(bindingNames(nil, arm.Pattern))...)
+	defer exitScope()
+
+	if p.peekTokenIs(token.IF) {
+		p.nextToken()
+		p.nextToken()
+
+		// the => after the guard ends it instead of starting an arrow function
+		guard := p.guard
+		p.guard = true
+		arm.Guard = p.parseExpression(LOWEST)
+		p.guard = guard
+	}
+
+	if !p.expectPeek(token.ARROW) {
+		return nil
+	}
+
+	if p.peekTokenIs(token.LBRACE) {
+		p.nextToken()
+		arm.Body = p.parseBlockStatement()
+		return arm
+	}
+
+	p.nextToken()
+
+	stmt := &ast.ExpressionStatement{Token: p.curToken, Expression: p.parseExpression(LOWEST)}
+	arm.Body = &ast.BlockStatement{Token: stmt.Token, Statements: []ast.Statement{stmt}}
+
+	return arm
+}
+
+// parseLiteralPattern parses the literal of a match arm, which may be a negative number.
+func (p *Parser) parseLiteralPattern() ast.Pattern {
+	if p.curTokenIs(token.MINUS) && !p.peekTokenIs(token.INT) && !p.peekTokenIs(token.FLOAT) {
+		p.errorAt(p.peekToken.Start, "expected a number after - in match pattern, got %s instead", p.peekToken.Type)
+		return nil
+	}
+
+	value := p.parseExpression(PREFIX)
+
+	switch value := value.(type) {
+	case *ast.IntegerLiteral, *ast.FloatLiteral, *ast.StringLiteral, *ast.Boolean, *ast.NullLiteral:
+		return &ast.LiteralPattern{Value: value}
+	case *ast.PrefixExpression:
+		switch value.Right.(type) {
+		case *ast.IntegerLiteral, *ast.FloatLiteral:
+			if value.Operator == "-" {
+				return &ast.LiteralPattern{Value: value}
+			}
+		}
+	}
+
+	if value != nil {
+		p.errorAt(value.Pos(), "expected a literal in match pattern, got %s", value.String())
+	}
+
+	return nil
+}
+
+// checkExhaustive warns about a match whose arms are all literals, since every other value
+// falls through to null. Arms for both true and false, or any unguarded _ or name, cover everything.
+func (p *Parser) checkExhaustive(exp *ast.MatchExpression) {
+	literals := []string{}
+	covered := map[string]bool{}
+
+	for _, arm := range exp.Arms {
+		switch pattern := arm.Pattern.(type) {
+		case *ast.LiteralPattern:
+			literals = append(literals, pattern.String())
+
+			if arm.Guard == nil {
+				covered[pattern.String()] = true
+			}
+		case *ast.WildcardPattern, *ast.Identifier:
+			if arm.Guard == nil {
+				return
+			}
+		default:
+			// array and hash patterns are not a set of literals
+			return
+		}
+	}
+
+	if len(literals) == 0 || covered["true"] && covered["false"] {
+		return
+	}
+
+	p.warnAt(exp.Pos(), "match is not exhaustive: add a _ arm for values other than %s", strings.Join(literals, ", "))
+}
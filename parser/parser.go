@@ -4,8 +4,11 @@ import (
 	"atom_script/ast"
 	"atom_script/lexer"
 	"atom_script/token"
+	"errors"
 	"fmt"
+	"math"
 	"strconv"
+	"strings"
 )
 
 type (
@@ -14,8 +17,21 @@ type (
 )
 
 type Parser struct {
-	l      *lexer.Lexer
-	errors []string
+	l        *lexer.Lexer
+	errors   []string
+	warnings []string
+
+	diagnosticsSeen int // how many of the lexer's diagnostics are already in errors
+
+	loops []string // labels of the loops around the current statement, innermost last ("" when unlabeled)
+	scope *scope   // names bound so far, for the molecule checks
+
+	grouping   int                     // how many parenthesised lists are open around the current expression
+	covered    []*ast.AssignExpression // assignments to molecules, arrays or hash literals that only an arrow function can accept
+	shorthands []*ast.Identifier       // hash literal keys without a value, which only an arrow function can accept
+
+	matching bool // parsing the pattern of a match arm, where literals and _ are allowed
+	guard    bool // parsing the guard of a match arm, which ends at =>
 
 	curToken  token.Token
 	peekToken token.Token
@@ -55,16 +71,21 @@ func New(l *lexer.Lexer) *Parser {
 	p := &Parser{
 		l:      l,
 		errors: []string{},
+		scope:  newScope(nil),
 	}
 
 	p.prefixParseFns = make(map[token.TokenType]prefixParseFn)
 	p.registerPrefix(token.IDENT, p.parseIdentifier)
 	p.registerPrefix(token.INT, p.parseIntegerLiteral)
+	p.registerPrefix(token.FLOAT, p.parseFloatLiteral)
 	p.registerPrefix(token.STRING, p.parseStringLiteral)
+	p.registerPrefix(token.STRING_HEAD, p.parseInterpolatedString)
 	p.registerPrefix(token.BANG, p.parsePrefixExpression)
 	p.registerPrefix(token.MINUS, p.parsePrefixExpression)
 	p.registerPrefix(token.TRUE, p.parseBooleanExpression)
 	p.registerPrefix(token.FALSE, p.parseBooleanExpression)
+	p.registerPrefix(token.NULL, p.parseNullLiteral)
+	p.registerPrefix(token.MATCH, p.parseMatchExpression)
 	p.registerPrefix(token.LPAREN, p.parseGroupedExpression)
 	p.registerPrefix(token.IF, p.parseIfExpression)
 	p.registerPrefix(token.REACTION, p.parseReactionLiteral)
@@ -80,8 +101,24 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerInfix(token.NOT_EQ, p.parseInfixExpression)
 	p.registerInfix(token.LT, p.parseInfixExpression)
 	p.registerInfix(token.GT, p.parseInfixExpression)
+	p.registerInfix(token.LT_EQ, p.parseInfixExpression)
+	p.registerInfix(token.GT_EQ, p.parseInfixExpression)
+	p.registerInfix(token.PERCENT, p.parseInfixExpression)
+	p.registerInfix(token.AND, p.parseInfixExpression)
+	p.registerInfix(token.OR, p.parseInfixExpression)
+	p.registerInfix(token.NULLISH, p.parseInfixExpression)
+	p.registerInfix(token.QUESTION, p.parseConditionalExpression)
+	p.registerInfix(token.PIPE, p.parsePipeExpression)
+	p.registerInfix(token.ASSIGN, p.parseAssignExpression)
+	p.registerInfix(token.PLUS_ASSIGN, p.parseAssignExpression)
+	p.registerInfix(token.MINUS_ASSIGN, p.parseAssignExpression)
+	p.registerInfix(token.ASTERISK_ASSIGN, p.parseAssignExpression)
+	p.registerInfix(token.SLASH_ASSIGN, p.parseAssignExpression)
+	p.registerInfix(token.PERCENT_ASSIGN, p.parseAssignExpression)
 	p.registerInfix(token.LPAREN, p.parseCallExpression)
 	p.registerInfix(token.LBRACKET, p.parseIndexExpression)
+	p.registerInfix(token.DOT, p.parseDotExpression)
+	p.registerInfix(token.OPTIONAL, p.parseOptionalChain)
 
 	// Read two tokens, so curToken and peekToken are both set
 	p.nextToken()
@@ -91,22 +128,69 @@ func New(l *lexer.Lexer) *Parser {
 }
 
 func (p *Parser) parseIdentifier() ast.Expression {
-	return &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	ident := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	if p.peekTokenIs(token.ARROW) && !p.guard {
+		return p.parseArrowFunction(ident.Token, []*ast.Parameter{{Token: ident.Token, Name: ident}})
+	}
+
+	return ident
 }
 
+// Errors returns the parse errors together with the lexer's diagnostics, in source order.
 func (p *Parser) Errors() []string {
 	return p.errors
 }
 
+// Warnings returns the problems that do not stop the program from running, such as a
+// match that is not exhaustive.
+func (p *Parser) Warnings() []string {
+	return p.warnings
+}
+
+// warnAt records a warning at the given source position.
+func (p *Parser) warnAt(pos token.Position, format string, a ...interface{}) {
+	p.warnings = append(p.warnings, pos.String()+": warning: "+fmt.Sprintf(format, a...))
+}
+
+// errorAt records a parse error at the given source position.
+func (p *Parser) errorAt(pos token.Position, format string, a ...interface{}) {
+	msg := fmt.Sprintf(format, a...)
+
+	if pos.IsValid() {
+		msg = pos.String() + ": " + msg
+	}
+
+	p.errors = append(p.errors, msg)
+}
+
 func (p *Parser) peekError(t token.TokenType) {
-	msg := fmt.Sprintf("expected next token to be %s, got %s instead",
+	p.errorAt(p.peekToken.Start, "expected next token to be %s, got %s instead",
 		t, p.peekToken.Type)
-	p.errors = append(p.errors, msg)
 }
 
+// nextToken advances the tokens, skipping comments in case the lexer is set to keep them.
 func (p *Parser) nextToken() {
 	p.curToken = p.peekToken
 	p.peekToken = p.l.NextToken()
+
+	for p.peekToken.Type == token.COMMENT {
+		p.peekToken = p.l.NextToken()
+	}
+
+	p.collectDiagnostics()
+}
+
+// collectDiagnostics adds the problems the lexer found since the last call to the errors.
+// Doing it as tokens are read keeps lexer and parser errors in source order.
+func (p *Parser) collectDiagnostics() {
+	diagnostics := p.l.Diagnostics()
+
+	for _, d := range diagnostics[p.diagnosticsSeen:] {
+		p.errors = append(p.errors, d.String())
+	}
+
+	p.diagnosticsSeen = len(diagnostics)
 }
 
 func (p *Parser) ParseProgram() *ast.Program {
@@ -139,8 +223,24 @@ func (p *Parser) parseStatement() ast.Statement {
 		return p.parseExpressionStatement()
 	case token.PRODUCE:
 		return p.praseProduceStatement()
+	case token.THROW:
+		return p.parseThrowStatement()
+	case token.TRY:
+		return p.parseTryStatement()
 	case token.MOLECULE:
 		return p.parseMoleculeStatement()
+	case token.WHILE:
+		return p.parseWhileStatement(nil)
+	case token.FOR:
+		return p.parseForStatement(nil)
+	case token.BREAK, token.CONTINUE:
+		return p.parseLoopControlStatement()
+	case token.IDENT:
+		if p.peekTokenIs(token.COLON) {
+			return p.parseLabeledStatement()
+		}
+
+		return p.parseExpressionStatement()
 	default:
 		return p.parseExpressionStatement()
 	}
@@ -151,13 +251,21 @@ func (p *Parser) parseAtomStatement() *ast.AtomStatement {
 		Token: p.curToken,
 	}
 
-	if !p.expectPeek(token.IDENT) {
-		return nil
-	}
+	if p.peekTokenIs(token.LBRACKET) || p.peekTokenIs(token.LBRACE) {
+		p.nextToken()
 
-	stmt.Name = &ast.Identifier{
-		Token: p.curToken,
-		Value: p.curToken.Literal,
+		if stmt.Pattern = p.parsePattern(); stmt.Pattern == nil {
+			return nil
+		}
+	} else {
+		if !p.expectPeek(token.IDENT) {
+			return nil
+		}
+
+		stmt.Name = &ast.Identifier{
+			Token: p.curToken,
+			Value: p.curToken.Literal,
+		}
 	}
 
 	if !p.expectPeek(token.ASSIGN) {
@@ -168,6 +276,8 @@ func (p *Parser) parseAtomStatement() *ast.AtomStatement {
 
 	stmt.Value = p.parseExpression(LOWEST)
 
+	p.declareBindings(stmt.Name, stmt.Pattern, false)
+
 	if p.peekTokenIs(token.SEMICOLON) {
 		p.nextToken()
 	}
@@ -180,13 +290,21 @@ func (p *Parser) parseMoleculeStatement() *ast.MoleculeStatement {
 		Token: p.curToken,
 	}
 
-	if !p.expectPeek(token.IDENT) {
-		return nil
-	}
+	if p.peekTokenIs(token.LBRACKET) || p.peekTokenIs(token.LBRACE) {
+		p.nextToken()
 
-	stmt.Name = &ast.Identifier{
-		Token: p.curToken,
-		Value: p.curToken.Literal,
+		if stmt.Pattern = p.parsePattern(); stmt.Pattern == nil {
+			return nil
+		}
+	} else {
+		if !p.expectPeek(token.IDENT) {
+			return nil
+		}
+
+		stmt.Name = &ast.Identifier{
+			Token: p.curToken,
+			Value: p.curToken.Literal,
+		}
 	}
 
 	if !p.expectPeek(token.ASSIGN) {
@@ -197,6 +315,8 @@ func (p *Parser) parseMoleculeStatement() *ast.MoleculeStatement {
 
 	stmt.Value = p.parseExpression(LOWEST)
 
+	p.declareBindings(stmt.Name, stmt.Pattern, true)
+
 	if p.peekTokenIs(token.SEMICOLON) {
 		p.nextToken()
 	}
@@ -220,6 +340,248 @@ func (p *Parser) praseProduceStatement() *ast.ProduceStatementStruct {
 	return stmt
 }
 
+func (p *Parser) parseThrowStatement() ast.Statement {
+	stmt := &ast.ThrowStatement{Token: p.curToken}
+
+	p.nextToken()
+
+	if stmt.Value = p.parseExpression(LOWEST); stmt.Value == nil {
+		return nil
+	}
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+// parseTryStatement parses try { } followed by catch (e) { }, catch { }, finally { } or both.
+func (p *Parser) parseTryStatement() ast.Statement {
+	stmt := &ast.TryStatement{Token: p.curToken}
+
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	stmt.Block = p.parseBlockStatement()
+
+	if p.peekTokenIs(token.CATCH) {
+		p.nextToken()
+
+		if p.peekTokenIs(token.LPAREN) {
+			p.nextToken()
+
+			if !p.expectPeek(token.IDENT) {
+				return nil
+			}
+
+			stmt.Param = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+			if !p.expectPeek(token.RPAREN) {
+				return nil
+			}
+		}
+
+		if !p.expectPeek(token.LBRACE) {
+			return nil
+		}
+
+		// the caught error is only visible in the catch block
+		exitScope := p.enterScope(stmt.Param)
+		stmt.Catch = p.parseBlockStatement()
+		exitScope()
+	}
+
+	if p.peekTokenIs(token.FINALLY) {
+		p.nextToken()
+
+		if !p.expectPeek(token.LBRACE) {
+			return nil
+		}
+
+		stmt.Finally = p.parseBlockStatement()
+	}
+
+	if stmt.Catch == nil && stmt.Finally == nil {
+		p.errorAt(p.peekToken.Start, "expected catch or finally after try block, got %s instead", p.peekToken.Type)
+		return nil
+	}
+
+	return stmt
+}
+
+// parseLabeledStatement parses `label: while (...)` and `label: for (...)`.
+func (p *Parser) parseLabeledStatement() ast.Statement {
+	label := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	for _, l := range p.loops {
+		if l == label.Value {
+			p.errorAt(label.Pos(), "loop label %s is already in use", label.Value)
+		}
+	}
+
+	p.nextToken()
+	p.nextToken()
+
+	switch p.curToken.Type {
+	case token.WHILE:
+		return p.parseWhileStatement(label)
+	case token.FOR:
+		return p.parseForStatement(label)
+	default:
+		p.errorAt(p.curToken.Start, "label %s must be followed by a loop, got %s instead",
+			label.Value, p.curToken.Type)
+		return nil
+	}
+}
+
+// parseLoopBody parses the body of a loop with the loop's label in scope for break and continue,
+// and the loop variables bound.
+func (p *Parser) parseLoopBody(label *ast.Identifier, vars ...*ast.Identifier) *ast.BlockStatement {
+	name := ""
+	if label != nil {
+		name = label.Value
+	}
+
+	p.loops = append(p.loops, name)
+	defer func() { p.loops = p.loops[:len(p.loops)-1] }()
+	defer p.enterScope(vars...)()
+
+	return p.parseBlockStatement()
+}
+
+// parseReactionBody parses the body of a reaction with its parameters bound.
+func (p *Parser) parseReactionBody(params []*ast.Parameter) *ast.BlockStatement {
+	defer p.enterReaction(params)()
+
+	return p.parseBlockStatement()
+}
+
+// enterReaction binds params in a new scope and hides the loops around the reaction,
+// which are out of reach inside it. It returns the function that undoes both.
+func (p *Parser) enterReaction(params []*ast.Parameter) func() {
+	loops := p.loops
+	p.loops = nil
+
+	exitScope := p.enterScope(parameterNames(params)...)
+
+	return func() {
+		exitScope()
+		p.loops = loops
+	}
+}
+
+func (p *Parser) parseLoopControlStatement() ast.Statement {
+	tok := p.curToken
+
+	var label *ast.Identifier
+
+	if p.peekTokenIs(token.IDENT) {
+		p.nextToken()
+		label = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	}
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+
+	if len(p.loops) == 0 {
+		p.errorAt(tok.Start, "%s outside of a loop", tok.Literal)
+		return nil
+	}
+
+	if label != nil && !p.hasLoopLabel(label.Value) {
+		p.errorAt(label.Pos(), "%s to unknown loop label %s", tok.Literal, label.Value)
+		return nil
+	}
+
+	if tok.Type == token.BREAK {
+		return &ast.BreakStatement{Token: tok, Label: label}
+	}
+
+	return &ast.ContinueStatement{Token: tok, Label: label}
+}
+
+func (p *Parser) hasLoopLabel(name string) bool {
+	for _, l := range p.loops {
+		if l == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p *Parser) parseWhileStatement(label *ast.Identifier) ast.Statement {
+	stmt := &ast.WhileStatement{Token: p.curToken, Label: label}
+
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+
+	p.nextToken()
+
+	stmt.Condition = p.parseExpression(LOWEST)
+
+	if !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	stmt.Body = p.parseLoopBody(label)
+
+	return stmt
+}
+
+func (p *Parser) parseForStatement(label *ast.Identifier) ast.Statement {
+	stmt := &ast.ForStatement{Token: p.curToken, Label: label}
+
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+
+	stmt.Value = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	if p.peekTokenIs(token.COMMA) {
+		p.nextToken()
+
+		if !p.expectPeek(token.IDENT) {
+			return nil
+		}
+
+		stmt.Key = stmt.Value
+		stmt.Value = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	}
+
+	if !p.expectPeek(token.IN) {
+		return nil
+	}
+
+	p.nextToken()
+
+	stmt.Iterable = p.parseExpression(LOWEST)
+
+	if !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	stmt.Body = p.parseLoopBody(label, stmt.Key, stmt.Value)
+
+	return stmt
+}
+
 func (p *Parser) parseReactionStatement() *ast.ReactionStatement {
 	stmt := &ast.ReactionStatement{
 		ReactionLiteral: &ast.ReactionLiteral{
@@ -236,6 +598,8 @@ func (p *Parser) parseReactionStatement() *ast.ReactionStatement {
 		Value: p.curToken.Literal,
 	}
 
+	p.declare(stmt.Name, false)
+
 	if !p.expectPeek(token.LPAREN) {
 		return nil
 	}
@@ -246,7 +610,7 @@ func (p *Parser) parseReactionStatement() *ast.ReactionStatement {
 		return nil
 	}
 
-	stmt.Body = p.parseBlockStatement()
+	stmt.Body = p.parseReactionBody(stmt.Parameters)
 
 	return stmt
 }
@@ -254,6 +618,12 @@ func (p *Parser) parseReactionStatement() *ast.ReactionStatement {
 const (
 	_ int = iota
 	LOWEST
+	ASSIGN      // = or +=
+	PIPE        // |>
+	TERNARY     // ? :
+	COALESCE    // ??
+	LOGICAL_OR  // ||
+	LOGICAL_AND // &&
 	EQUALS      // ==
 	LESSGREATER // > or <
 	SUM         // +
@@ -264,16 +634,32 @@ const (
 )
 
 var precedences = map[token.TokenType]int{
-	token.EQ:       EQUALS,
-	token.NOT_EQ:   EQUALS,
-	token.LT:       LESSGREATER,
-	token.GT:       LESSGREATER,
-	token.PLUS:     SUM,
-	token.MINUS:    SUM,
-	token.SLASH:    PRODUCT,
-	token.ASTERISK: PRODUCT,
-	token.LPAREN:   CALL,
-	token.LBRACKET: INDEX,
+	token.ASSIGN:          ASSIGN,
+	token.PLUS_ASSIGN:     ASSIGN,
+	token.MINUS_ASSIGN:    ASSIGN,
+	token.ASTERISK_ASSIGN: ASSIGN,
+	token.SLASH_ASSIGN:    ASSIGN,
+	token.PERCENT_ASSIGN:  ASSIGN,
+	token.EQ:              EQUALS,
+	token.NOT_EQ:          EQUALS,
+	token.LT:              LESSGREATER,
+	token.GT:              LESSGREATER,
+	token.LT_EQ:           LESSGREATER,
+	token.GT_EQ:           LESSGREATER,
+	token.PIPE:            PIPE,
+	token.QUESTION:        TERNARY,
+	token.NULLISH:         COALESCE,
+	token.OR:              LOGICAL_OR,
+	token.AND:             LOGICAL_AND,
+	token.PERCENT:         PRODUCT,
+	token.PLUS:            SUM,
+	token.MINUS:           SUM,
+	token.SLASH:           PRODUCT,
+	token.ASTERISK:        PRODUCT,
+	token.LPAREN:          CALL,
+	token.LBRACKET:        INDEX,
+	token.DOT:             INDEX,
+	token.OPTIONAL:        INDEX,
 }
 
 func (p *Parser) peekPrecedence() int {
@@ -305,8 +691,7 @@ func (p *Parser) parseExpressionStatement() *ast.ExpressionStatement {
 }
 
 func (p *Parser) noPrefixParseFnError(t token.TokenType) {
-	msg := fmt.Sprintf("no prefix parse function for %s found", t)
-	p.errors = append(p.errors, msg)
+	p.errorAt(p.curToken.Start, "no prefix parse function for %s found", t)
 }
 
 func (p *Parser) parseExpression(precedence int) ast.Expression {
@@ -338,11 +723,21 @@ func (p *Parser) parseIntegerLiteral() ast.Expression {
 		Token: p.curToken,
 	}
 
+	if hasLeadingZero(p.curToken.Literal) {
+		p.errorAt(p.curToken.Start, "integer literal %s has a leading zero (%s)",
+			p.curToken.Literal, leadingZeroHint(p.curToken.Literal))
+		return nil
+	}
+
 	value, err := strconv.ParseInt(p.curToken.Literal, 0, 64)
 
 	if err != nil {
-		msg := fmt.Sprintf("could not parse %q as interger", p.curToken.Literal)
-		p.errors = append(p.errors, msg)
+		if errors.Is(err, strconv.ErrRange) {
+			p.errorAt(p.curToken.Start, "integer literal %s overflows int64 (max %d)",
+				p.curToken.Literal, int64(math.MaxInt64))
+		} else {
+			p.errorAt(p.curToken.Start, "could not parse %q as integer", p.curToken.Literal)
+		}
 
 		return nil
 	}
@@ -352,57 +747,484 @@ func (p *Parser) parseIntegerLiteral() ast.Expression {
 	return lit
 }
 
-func (p *Parser) parseStringLiteral() ast.Expression {
-	return &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal}
+// hasLeadingZero reports whether a decimal integer literal starts with 0, which strconv would read
+// as octal. Octal is written with 0o instead.
+func hasLeadingZero(literal string) bool {
+	return len(literal) > 1 && literal[0] == '0' && (isDecimalDigit(literal[1]) || literal[1] == '_')
 }
 
-func (p *Parser) parsePrefixExpression() ast.Expression {
-	expression := &ast.PrefixExpression{
-		Token:    p.curToken,
-		Operator: p.curToken.Literal,
+// leadingZeroHint suggests what a literal with a leading zero was meant to be.
+func leadingZeroHint(literal string) string {
+	digits := strings.TrimLeft(literal, "0_")
+	if digits == "" {
+		return "use 0"
 	}
 
-	p.nextToken()
+	if strings.Trim(digits, "01234567_") == "" {
+		return fmt.Sprintf("use 0o%s for octal or %s for decimal", digits, digits)
+	}
 
-	expression.Right = p.parseExpression(PREFIX)
+	return fmt.Sprintf("use %s", digits)
+}
 
-	return expression
+func isDecimalDigit(ch byte) bool {
+	return '0' <= ch && ch <= '9'
 }
 
-func (p *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
-	expression := &ast.InfixExpression{
-		Token:    p.curToken,
-		Operator: p.curToken.Literal,
-		Left:     left,
+func (p *Parser) parseFloatLiteral() ast.Expression {
+	lit := &ast.FloatLiteral{
+		Token: p.curToken,
 	}
 
-	precedence := p.curPrecedence()
+	value, err := strconv.ParseFloat(p.curToken.Literal, 64)
 
-	p.nextToken()
+	if err != nil {
+		if errors.Is(err, strconv.ErrRange) {
+			p.errorAt(p.curToken.Start, "float literal %s is out of range", p.curToken.Literal)
+		} else {
+			p.errorAt(p.curToken.Start, "could not parse %q as float", p.curToken.Literal)
+		}
 
-	expression.Right = p.parseExpression(precedence)
+		return nil
+	}
 
-	return expression
+	lit.Value = value
+
+	return lit
 }
 
-func (p *Parser) parseBooleanExpression() ast.Expression {
-	return &ast.Boolean{
-		Token: p.curToken,
-		Value: p.curTokenIs(token.TRUE),
-	}
+func (p *Parser) parseStringLiteral() ast.Expression {
+	return &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal}
 }
 
-func (p *Parser) parseGroupedExpression() ast.Expression {
+func (p *Parser) parseInterpolatedString() ast.Expression {
+	str := &ast.InterpolatedString{Token: p.curToken}
+
+	for {
+		if p.curToken.Literal != "" {
+			str.Parts = append(str.Parts, &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal})
+		}
+
+		if p.curTokenIs(token.STRING_TAIL) {
+			return str
+		}
+
+		p.nextToken()
+
+		str.Parts = append(str.Parts, p.parseExpression(LOWEST))
+
+		if !p.peekTokenIs(token.STRING_MIDDLE) && !p.peekTokenIs(token.STRING_TAIL) {
+			p.errorAt(p.peekToken.Start, "expected } to close string interpolation, got %s instead",
+				p.peekToken.Type)
+			return nil
+		}
+
+		p.nextToken()
+	}
+}
+
+func (p *Parser) parsePrefixExpression() ast.Expression {
+	expression := &ast.PrefixExpression{
+		Token:    p.curToken,
+		Operator: p.curToken.Literal,
+	}
+
 	p.nextToken()
-	exp := p.parseExpression(LOWEST)
 
-	if !p.expectPeek(token.RPAREN) {
+	expression.Right = p.parseExpression(PREFIX)
+
+	return expression
+}
+
+func (p *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
+	expression := &ast.InfixExpression{
+		Token:    p.curToken,
+		Operator: p.curToken.Literal,
+		Left:     left,
+	}
+
+	precedence := p.curPrecedence()
+
+	p.nextToken()
+
+	expression.Right = p.parseExpression(precedence)
+
+	return expression
+}
+
+// parseAssignExpression parses the right side one level below ASSIGN so that
+// a = b = c groups as a = (b = c).
+func (p *Parser) parseAssignExpression(target ast.Expression) ast.Expression {
+	expression := &ast.AssignExpression{
+		Token:    p.curToken,
+		Target:   target,
+		Operator: p.curToken.Literal,
+	}
+
+	if target == nil {
+		// the target already failed to parse and reported why
 		return nil
 	}
 
+	switch target := target.(type) {
+	case *ast.Identifier:
+		if _, molecule := p.scope.lookup(target.Value); molecule {
+			// (x = 1) => x gives a parameter that shadows the molecule a default;
+			// the enclosing group reports the error once it knows no => follows
+			if p.grouping > 0 && expression.Operator == "=" {
+				p.covered = append(p.covered, expression)
+			} else {
+				p.errorAt(target.Pos(), "cannot assign to molecule %s", target.Value)
+			}
+		}
+	case *ast.IndexExpression:
+		// elements of a molecule's array or hash can still be changed
+		if isOptionalChain(target) {
+			p.errorAt(target.Pos(), "cannot assign to optional chain %s", target.String())
+		}
+	case *ast.ArrayLiteral, *ast.HashLiteral:
+		// ([a, b] = pair) => ... gives a destructured parameter a default; the enclosing
+		// group reports the error once it knows no => follows
+		if p.grouping > 0 {
+			p.covered = append(p.covered, expression)
+		} else {
+			p.errorAt(target.Pos(), "cannot assign to %s", target.String())
+		}
+	default:
+		p.errorAt(target.Pos(), "cannot assign to %s", target.String())
+	}
+
+	p.nextToken()
+
+	expression.Value = p.parseExpression(ASSIGN - 1)
+
+	return expression
+}
+
+// parseConditionalExpression parses cond ? a : b. The alternative is parsed one level below
+// TERNARY so that a ? b : c ? d : e groups as a ? b : (c ? d : e).
+func (p *Parser) parseConditionalExpression(condition ast.Expression) ast.Expression {
+	exp := &ast.ConditionalExpression{Token: p.curToken, Condition: condition}
+
+	p.nextToken()
+
+	exp.Consequence = p.parseExpression(LOWEST)
+
+	if !p.expectPeek(token.COLON) {
+		return nil
+	}
+
+	p.nextToken()
+
+	exp.Alternative = p.parseExpression(TERNARY - 1)
+
 	return exp
 }
 
+// parsePipeExpression turns x |> f into f(x) and x |> f(y) into f(x, y).
+func (p *Parser) parsePipeExpression(left ast.Expression) ast.Expression {
+	tok := p.curToken
+
+	p.nextToken()
+
+	right := p.parseExpression(PIPE)
+
+	if call, ok := right.(*ast.CallExpression); ok {
+		call.Arguments = append([]ast.Expression{left}, call.Arguments...)
+		return call
+	}
+
+	return &ast.CallExpression{Token: tok, Function: right, Arguments: []ast.Expression{left}}
+}
+
+// parseOptionalChain parses a?.[index], a?.name and f?.(args).
+func (p *Parser) parseOptionalChain(left ast.Expression) ast.Expression {
+	tok := p.curToken
+
+	switch p.peekToken.Type {
+	case token.LBRACKET:
+		p.nextToken()
+
+		exp, ok := p.parseIndexExpression(left).(*ast.IndexExpression)
+		if !ok {
+			return nil
+		}
+
+		exp.Token, exp.Optional = tok, true
+		return exp
+
+	case token.LPAREN:
+		p.nextToken()
+
+		exp := p.parseCallExpression(left).(*ast.CallExpression)
+		exp.Token, exp.Optional = tok, true
+		return exp
+
+	case token.IDENT:
+		exp := p.parseDotExpression(left).(*ast.IndexExpression)
+		exp.Optional = true
+		return exp
+
+	default:
+		p.errorAt(p.peekToken.Start, "expected [, ( or a name after ?., got %s instead", p.peekToken.Type)
+		return nil
+	}
+}
+
+// isOptionalChain reports whether any link of a chain of index and call expressions uses ?.
+func isOptionalChain(exp ast.Expression) bool {
+	for {
+		switch e := exp.(type) {
+		case *ast.IndexExpression:
+			if e.Optional {
+				return true
+			}
+			exp = e.Left
+		case *ast.CallExpression:
+			if e.Optional {
+				return true
+			}
+			exp = e.Function
+		default:
+			return false
+		}
+	}
+}
+
+func (p *Parser) parseNullLiteral() ast.Expression {
+	return &ast.NullLiteral{Token: p.curToken}
+}
+
+func (p *Parser) parseBooleanExpression() ast.Expression {
+	return &ast.Boolean{
+		Token: p.curToken,
+		Value: p.curTokenIs(token.TRUE),
+	}
+}
+
+// parseGroupedExpression parses (exp), and also the parameter list of an arrow function:
+// the contents are parsed as expressions first, and only turned into parameters once => shows up.
+func (p *Parser) parseGroupedExpression() ast.Expression {
+	start := p.curToken
+
+	if p.peekTokenIs(token.RPAREN) {
+		p.nextToken()
+
+		if !p.peekTokenIs(token.ARROW) {
+			p.peekError(token.ARROW)
+			return nil
+		}
+
+		return p.parseArrowFunction(start, []*ast.Parameter{})
+	}
+
+	exps := []ast.Expression{}
+
+	var rest *ast.Parameter
+
+	p.grouping++
+	mark, shorthandMark := len(p.covered), len(p.shorthands)
+
+	defer func() { p.covered, p.shorthands = p.covered[:mark], p.shorthands[:shorthandMark] }()
+
+	// inside the parentheses => can only start an arrow function, even in a match guard;
+	// only a => right after them is ambiguous there
+	guard := p.guard
+	p.guard = false
+
+	defer func() { p.guard = guard }()
+
+	for {
+		p.nextToken()
+
+		if p.curTokenIs(token.ELLIPSIS) {
+			if rest = p.parseParameter(); rest == nil {
+				p.grouping--
+				return nil
+			}
+
+			break
+		}
+
+		exp := p.parseExpression(LOWEST)
+		if exp == nil {
+			p.grouping--
+			return nil
+		}
+
+		exps = append(exps, exp)
+
+		if !p.peekTokenIs(token.COMMA) {
+			break
+		}
+
+		p.nextToken()
+	}
+
+	p.grouping--
+
+	if !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+
+	used := map[ast.Expression]bool{}
+	defer func() {
+		for _, assign := range p.covered[mark:] {
+			if used[assign] {
+				continue
+			}
+
+			if name, ok := assign.Target.(*ast.Identifier); ok {
+				p.errorAt(name.Pos(), "cannot assign to molecule %s", name.Value)
+			} else {
+				p.errorAt(assign.Target.Pos(), "cannot assign to %s", assign.Target.String())
+			}
+		}
+
+		for _, key := range p.shorthands[shorthandMark:] {
+			if !used[key] {
+				p.errorAt(key.Pos(), "expected : and a value after hash key %s", key.Value)
+			}
+		}
+	}()
+
+	if p.peekTokenIs(token.ARROW) && !guard {
+		params := []*ast.Parameter{}
+
+		for _, exp := range exps {
+			param := coverParameter(exp, used)
+			if param == nil {
+				p.errorAt(exp.Pos(), "invalid arrow function parameter %s", exp.String())
+				return nil
+			}
+
+			params = append(params, param)
+		}
+
+		if rest != nil {
+			params = append(params, rest)
+		}
+
+		p.checkParameters(params)
+
+		return p.parseArrowFunction(start, params)
+	}
+
+	if rest != nil || len(exps) > 1 {
+		p.peekError(token.ARROW)
+		return nil
+	}
+
+	return exps[0]
+}
+
+// coverParameter turns an expression from a parenthesised list into an arrow function parameter:
+// x becomes a plain parameter and x = value one with a default. Anything else is not a parameter.
+// Array and hash literals made of names become patterns; the assignments taken as defaults and
+// the hash keys taken as pattern keys are recorded in used.
+func coverParameter(exp ast.Expression, used map[ast.Expression]bool) *ast.Parameter {
+	element := coverPatternElement(exp, used)
+	if element == nil {
+		return nil
+	}
+
+	param := &ast.Parameter{Default: element.Default}
+
+	switch target := element.Target.(type) {
+	case *ast.Identifier:
+		param.Token, param.Name = target.Token, target
+	case *ast.ArrayPattern:
+		param.Token, param.Pattern = target.Token, target
+	case *ast.HashPattern:
+		param.Token, param.Pattern = target.Token, target
+	}
+
+	return param
+}
+
+// coverPatternElement reads an expression as a pattern, with x = value giving a default.
+func coverPatternElement(exp ast.Expression, used map[ast.Expression]bool) *ast.PatternElement {
+	switch exp := exp.(type) {
+	case *ast.Identifier:
+		return &ast.PatternElement{Target: exp}
+
+	case *ast.AssignExpression:
+		if exp.Operator != "=" {
+			return nil
+		}
+
+		if element := coverPatternElement(exp.Target, used); element != nil && element.Default == nil {
+			element.Default = exp.Value
+			used[exp] = true
+			return element
+		}
+
+	case *ast.ArrayLiteral:
+		pattern := &ast.ArrayPattern{Token: exp.Token}
+
+		for i, el := range exp.Elements {
+			if spread, ok := el.(*ast.SpreadElement); ok {
+				if pattern.Rest = coverRest(spread, i, len(exp.Elements)); pattern.Rest == nil {
+					return nil
+				}
+
+				continue
+			}
+
+			element := coverPatternElement(el, used)
+			if element == nil {
+				return nil
+			}
+
+			pattern.Elements = append(pattern.Elements, element)
+		}
+
+		return &ast.PatternElement{Target: pattern}
+
+	case *ast.HashLiteral:
+		pattern := &ast.HashPattern{Token: exp.Token}
+
+		for i, key := range exp.Order {
+			if spread, ok := key.(*ast.SpreadElement); ok {
+				if pattern.Rest = coverRest(spread, i, len(exp.Order)); pattern.Rest == nil {
+					return nil
+				}
+
+				continue
+			}
+
+			name, ok := key.(*ast.Identifier)
+			if !ok {
+				return nil
+			}
+
+			element := coverPatternElement(exp.Pairs[key], used)
+			if element == nil {
+				return nil
+			}
+
+			used[name] = true
+
+			pattern.Entries = append(pattern.Entries, &ast.HashPatternEntry{Key: name, PatternElement: element})
+		}
+
+		return &ast.PatternElement{Target: pattern}
+	}
+
+	return nil
+}
+
+// coverRest reads the spread at index i of a list of n elements as a pattern's ...rest,
+// which has to be a name and come last.
+func coverRest(spread *ast.SpreadElement, i, n int) *ast.Identifier {
+	name, ok := spread.Value.(*ast.Identifier)
+	if !ok || i != n-1 {
+		return nil
+	}
+
+	return name
+}
+
 func (p *Parser) parseIfExpression() ast.Expression {
 	expression := &ast.IfExpression{Token: p.curToken}
 
@@ -422,7 +1244,9 @@ func (p *Parser) parseIfExpression() ast.Expression {
 		return nil
 	}
 
-	expression.Consequence = p.parseBlockStatement()
+	var consequenceBound, alternativeBound map[string]bool
+
+	expression.Consequence, consequenceBound = p.parseBranch()
 
 	if p.peekTokenIs(token.ELSE) {
 		p.nextToken()
@@ -431,9 +1255,11 @@ func (p *Parser) parseIfExpression() ast.Expression {
 			return nil
 		}
 
-		expression.Alternative = p.parseBlockStatement()
+		expression.Alternative, alternativeBound = p.parseBranch()
 	}
 
+	p.mergeBranches(consequenceBound, alternativeBound)
+
 	return expression
 }
 
@@ -469,36 +1295,340 @@ func (p *Parser) parseReactionLiteral() ast.Expression {
 		return nil
 	}
 
-	rl.Body = p.parseBlockStatement()
+	rl.Body = p.parseReactionBody(rl.Parameters)
 
 	return rl
 }
 
-func (p *Parser) parseReactionParameters() []*ast.Identifier {
-	identifiers := []*ast.Identifier{}
+// parseArrowFunction parses the part of (params) => body after the parameters and desugars it into
+// a ReactionLiteral. A body that is not a { block } becomes a block holding that single expression.
+func (p *Parser) parseArrowFunction(start token.Token, params []*ast.Parameter) ast.Expression {
+	rl := &ast.ReactionLiteral{
+		Token:      token.Token{Type: token.REACTION, Literal: "reaction", Start: start.Start, End: start.End},
+		Parameters: params,
+	}
 
-	if p.peekTokenIs(token.RPAREN) {
+	p.nextToken() // the =>
+
+	if p.peekTokenIs(token.LBRACE) {
 		p.nextToken()
-		return identifiers
+		rl.Body = p.parseReactionBody(params)
+		return rl
 	}
 
 	p.nextToken()
 
-	ident := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
-	identifiers = append(identifiers, ident)
+	exitReaction := p.enterReaction(params)
+	stmt := &ast.ExpressionStatement{Token: p.curToken, Expression: p.parseExpression(LOWEST)}
+	exitReaction()
 
-	for p.peekTokenIs(token.COMMA) {
+	rl.Body = &ast.BlockStatement{Token: stmt.Token, Statements: []ast.Statement{stmt}}
+
+	return rl
+}
+
+// parsePattern parses the target of a binding: a name, [a, b = 1, ...rest] or {name, symbol: sym, ...rest}.
+func (p *Parser) parsePattern() ast.Pattern {
+	switch p.curToken.Type {
+	case token.IDENT:
+		if p.matching && p.curToken.Literal == "_" {
+			return &ast.WildcardPattern{Token: p.curToken}
+		}
+
+		return &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	case token.LBRACKET:
+		return p.parseArrayPattern()
+	case token.LBRACE:
+		return p.parseHashPattern()
+	}
+
+	if !p.matching {
+		p.errorAt(p.curToken.Start, "expected a name, [ or { in pattern, got %s instead", p.curToken.Type)
+		return nil
+	}
+
+	switch p.curToken.Type {
+	case token.INT, token.FLOAT, token.STRING, token.STRING_HEAD, token.TRUE, token.FALSE, token.NULL, token.MINUS:
+		return p.parseLiteralPattern()
+	default:
+		p.errorAt(p.curToken.Start, "expected a literal, name, _, [ or { in match pattern, got %s instead", p.curToken.Type)
+		return nil
+	}
+}
+
+// parsePatternElement parses a pattern followed by an optional = default.
+func (p *Parser) parsePatternElement() *ast.PatternElement {
+	target := p.parsePattern()
+	if target == nil {
+		return nil
+	}
+
+	element := &ast.PatternElement{Target: target}
+
+	if p.peekTokenIs(token.ASSIGN) {
+		p.nextToken()
+		p.nextToken()
+		element.Default = p.parseExpression(LOWEST)
+	}
+
+	return element
+}
+
+// parsePatternRest parses the ...name that may end a pattern.
+func (p *Parser) parsePatternRest() *ast.Identifier {
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+
+	return &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+}
+
+func (p *Parser) parseArrayPattern() ast.Pattern {
+	pattern := &ast.ArrayPattern{Token: p.curToken}
+
+	for !p.peekTokenIs(token.RBRACKET) {
 		p.nextToken()
+
+		if p.curTokenIs(token.ELLIPSIS) {
+			if pattern.Rest = p.parsePatternRest(); pattern.Rest == nil {
+				return nil
+			}
+
+			break
+		}
+
+		element := p.parsePatternElement()
+		if element == nil {
+			return nil
+		}
+
+		pattern.Elements = append(pattern.Elements, element)
+
+		if !p.peekTokenIs(token.COMMA) {
+			break
+		}
+
+		p.nextToken()
+	}
+
+	if !p.expectPeek(token.RBRACKET) {
+		return nil
+	}
+
+	return pattern
+}
+
+func (p *Parser) parseHashPattern() ast.Pattern {
+	pattern := &ast.HashPattern{Token: p.curToken}
+
+	for !p.peekTokenIs(token.RBRACE) {
+		p.nextToken()
+
+		if p.curTokenIs(token.ELLIPSIS) {
+			if pattern.Rest = p.parsePatternRest(); pattern.Rest == nil {
+				return nil
+			}
+
+			break
+		}
+
+		if !p.curTokenIs(token.IDENT) {
+			p.errorAt(p.curToken.Start, "expected a key name in hash pattern, got %s instead", p.curToken.Type)
+			return nil
+		}
+
+		key := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+		entry := &ast.HashPatternEntry{Key: key, PatternElement: &ast.PatternElement{Target: key}}
+
+		if p.peekTokenIs(token.COLON) {
+			p.nextToken()
+			p.nextToken()
+
+			if entry.PatternElement = p.parsePatternElement(); entry.PatternElement == nil {
+				return nil
+			}
+		} else if p.peekTokenIs(token.ASSIGN) {
+			p.nextToken()
+			p.nextToken()
+			entry.Default = p.parseExpression(LOWEST)
+		}
+
+		pattern.Entries = append(pattern.Entries, entry)
+
+		if !p.peekTokenIs(token.COMMA) {
+			break
+		}
+
+		p.nextToken()
+	}
+
+	if !p.expectPeek(token.RBRACE) {
+		return nil
+	}
+
+	return pattern
+}
+
+// declareBindings declares every name bound by an atom or molecule statement.
+func (p *Parser) declareBindings(name *ast.Identifier, pattern ast.Pattern, molecule bool) {
+	for _, name := range p.distinctBindings(bindingNames(name, pattern)) {
+		p.declare(name, molecule)
+	}
+}
+
+// distinctBindings reports the names that a pattern binds more than once and returns the others.
+func (p *Parser) distinctBindings(names []*ast.Identifier) []*ast.Identifier {
+	seen := map[string]bool{}
+	distinct := []*ast.Identifier{}
+
+	for _, name := range names {
+		if seen[name.Value] {
+			p.errorAt(name.Pos(), "duplicate binding %s", name.Value)
+			continue
+		}
+
+		seen[name.Value] = true
+		distinct = append(distinct, name)
+	}
+
+	return distinct
+}
+
+// bindingNames lists the names a binding introduces: name itself, or every name in pattern.
+func bindingNames(name *ast.Identifier, pattern ast.Pattern) []*ast.Identifier {
+	if pattern == nil {
+		return []*ast.Identifier{name}
+	}
+
+	switch pattern := pattern.(type) {
+	case *ast.Identifier:
+		return []*ast.Identifier{pattern}
+
+	case *ast.ArrayPattern:
+		names := []*ast.Identifier{}
+		for _, el := range pattern.Elements {
+			names = append(names, bindingNames(nil, el.Target)...)
+		}
+
+		if pattern.Rest != nil {
+			names = append(names, pattern.Rest)
+		}
+
+		return names
+
+	case *ast.HashPattern:
+		names := []*ast.Identifier{}
+		for _, entry := range pattern.Entries {
+			names = append(names, bindingNames(nil, entry.Target)...)
+		}
+
+		if pattern.Rest != nil {
+			names = append(names, pattern.Rest)
+		}
+
+		return names
+	}
+
+	return nil
+}
+
+func parameterNames(params []*ast.Parameter) []*ast.Identifier {
+	names := []*ast.Identifier{}
+
+	for _, param := range params {
+		names = append(names, bindingNames(param.Name, param.Pattern)...)
+	}
+
+	return names
+}
+
+func (p *Parser) parseReactionParameters() []*ast.Parameter {
+	params := []*ast.Parameter{}
+
+	if p.peekTokenIs(token.RPAREN) {
+		p.nextToken()
+		return params
+	}
+
+	for {
+		p.nextToken()
+
+		param := p.parseParameter()
+		if param == nil {
+			return nil
+		}
+
+		params = append(params, param)
+
+		if !p.peekTokenIs(token.COMMA) {
+			break
+		}
+
 		p.nextToken()
-		ident := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
-		identifiers = append(identifiers, ident)
 	}
 
 	if !p.expectPeek(token.RPAREN) {
 		return nil
 	}
 
-	return identifiers
+	p.checkParameters(params)
+
+	return params
+}
+
+// parseParameter parses name, name = default or ...name.
+func (p *Parser) parseParameter() *ast.Parameter {
+	param := &ast.Parameter{Token: p.curToken}
+
+	switch {
+	case p.curTokenIs(token.ELLIPSIS):
+		param.Rest = true
+
+		if !p.expectPeek(token.IDENT) {
+			return nil
+		}
+
+		param.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	case p.curTokenIs(token.LBRACKET) || p.curTokenIs(token.LBRACE):
+		if param.Pattern = p.parsePattern(); param.Pattern == nil {
+			return nil
+		}
+
+	case p.curTokenIs(token.IDENT):
+		param.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	default:
+		p.errorAt(p.curToken.Start, "expected parameter name, got %s instead", p.curToken.Type)
+		return nil
+	}
+
+	if !param.Rest && p.peekTokenIs(token.ASSIGN) {
+		p.nextToken()
+		p.nextToken()
+		param.Default = p.parseExpression(LOWEST)
+	}
+
+	return param
+}
+
+// checkParameters reports repeated names and a rest parameter that is not the last one.
+func (p *Parser) checkParameters(params []*ast.Parameter) {
+	seen := map[string]bool{}
+
+	for _, name := range parameterNames(params) {
+		if seen[name.Value] {
+			p.errorAt(name.Pos(), "duplicate parameter %s", name.Value)
+		}
+
+		seen[name.Value] = true
+	}
+
+	for i, param := range params {
+		if param.Rest && i != len(params)-1 {
+			p.errorAt(param.Pos(), "rest parameter ...%s must be the last parameter", param.Name.Value)
+		}
+	}
 }
 
 func (p *Parser) parseArrayLiteral() ast.Expression {
@@ -517,12 +1647,12 @@ func (p *Parser) parseExpressionList(end token.TokenType) []ast.Expression {
 
 	p.nextToken()
 
-	list = append(list, p.parseExpression(LOWEST))
+	list = append(list, p.parseListElement())
 
 	for p.peekTokenIs(token.COMMA) {
 		p.nextToken()
 		p.nextToken()
-		list = append(list, p.parseExpression(LOWEST))
+		list = append(list, p.parseListElement())
 	}
 
 	if !p.expectPeek(end) {
@@ -532,13 +1662,88 @@ func (p *Parser) parseExpressionList(end token.TokenType) []ast.Expression {
 	return list
 }
 
+// parseListElement parses one element of a list, which may be spread with ...value.
+func (p *Parser) parseListElement() ast.Expression {
+	if !p.curTokenIs(token.ELLIPSIS) {
+		return p.parseExpression(LOWEST)
+	}
+
+	spread := &ast.SpreadElement{Token: p.curToken}
+
+	p.nextToken()
+
+	if spread.Value = p.parseExpression(LOWEST); spread.Value == nil {
+		return nil
+	}
+
+	return spread
+}
+
 func (p *Parser) parseCallExpression(function ast.Expression) ast.Expression {
 	exp := &ast.CallExpression{Token: p.curToken, Function: function}
-	exp.Arguments = p.parseExpressionList(token.RPAREN)
+	exp.Arguments = p.parseCallArguments()
 
 	return exp
 }
 
+// parseCallArguments parses positional arguments followed by name: value arguments.
+func (p *Parser) parseCallArguments() []ast.Expression {
+	args := []ast.Expression{}
+
+	if p.peekTokenIs(token.RPAREN) {
+		p.nextToken()
+		return args
+	}
+
+	named := map[string]bool{}
+
+	// an arrow function passed as an argument can appear in a match guard
+	guard := p.guard
+	p.guard = false
+
+	defer func() { p.guard = guard }()
+
+	for {
+		p.nextToken()
+
+		if p.curTokenIs(token.IDENT) && p.peekTokenIs(token.COLON) {
+			arg := &ast.NamedArgument{Token: p.curToken, Name: &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}}
+
+			p.nextToken()
+			p.nextToken()
+
+			arg.Value = p.parseExpression(LOWEST)
+
+			if named[arg.Name.Value] {
+				p.errorAt(arg.Pos(), "duplicate named argument %s", arg.Name.Value)
+			}
+
+			named[arg.Name.Value] = true
+			args = append(args, arg)
+		} else {
+			arg := p.parseListElement()
+
+			if len(named) > 0 && arg != nil {
+				p.errorAt(arg.Pos(), "positional argument %s after named arguments", arg.String())
+			}
+
+			args = append(args, arg)
+		}
+
+		if !p.peekTokenIs(token.COMMA) {
+			break
+		}
+
+		p.nextToken()
+	}
+
+	if !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+
+	return args
+}
+
 func (p *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
 	exp := &ast.IndexExpression{Token: p.curToken, Left: left}
 
@@ -553,6 +1758,22 @@ func (p *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
 	return exp
 }
 
+// parseDotExpression parses hash.name as hash["name"].
+func (p *Parser) parseDotExpression(left ast.Expression) ast.Expression {
+	exp := &ast.IndexExpression{Token: p.curToken, Left: left, Field: true}
+
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+
+	exp.Index = &ast.StringLiteral{
+		Token: token.Token{Type: token.STRING, Literal: p.curToken.Literal, Start: p.curToken.Start, End: p.curToken.End},
+		Value: p.curToken.Literal,
+	}
+
+	return exp
+}
+
 func (p *Parser) parseHashLiteral() ast.Expression {
 	hash := &ast.HashLiteral{Token: p.curToken}
 	hash.Pairs = make(map[ast.Expression]ast.Expression)
@@ -562,35 +1783,39 @@ func (p *Parser) parseHashLiteral() ast.Expression {
 		return hash
 	}
 
-	p.nextToken()
-
-	key := p.parseExpression(LOWEST)
-
-	if !p.expectPeek(token.COLON) {
-		return nil
-	}
-
-	p.nextToken()
-
-	value := p.parseExpression(LOWEST)
-
-	hash.Pairs[key] = value
-
-	for p.peekTokenIs(token.COMMA) {
-		p.nextToken()
+	for {
 		p.nextToken()
 
-		key := p.parseExpression(LOWEST)
+		if p.curTokenIs(token.ELLIPSIS) {
+			spread := p.parseListElement()
+			if spread == nil {
+				return nil
+			}
+
+			hash.Order = append(hash.Order, spread)
+		} else if key := p.parseExpression(LOWEST); p.isShorthand(key) {
+			// {name} or {name = value}, read as an arrow function's hash pattern
+			name := shorthandName(key)
+			p.shorthands = append(p.shorthands, name)
+
+			hash.Pairs[name] = key
+			hash.Order = append(hash.Order, name)
+		} else {
+			if !p.expectPeek(token.COLON) {
+				return nil
+			}
+
+			p.nextToken()
+
+			hash.Pairs[key] = p.parseExpression(LOWEST)
+			hash.Order = append(hash.Order, key)
+		}
 
-		if !p.expectPeek(token.COLON) {
-			return nil
+		if !p.peekTokenIs(token.COMMA) {
+			break
 		}
 
 		p.nextToken()
-
-		value := p.parseExpression(LOWEST)
-
-		hash.Pairs[key] = value
 	}
 
 	if !p.expectPeek(token.RBRACE) {
@@ -599,3 +1824,25 @@ func (p *Parser) parseHashLiteral() ast.Expression {
 
 	return hash
 }
+
+// isShorthand reports whether a hash literal key has no value after it. That is only allowed inside
+// parentheses, where the hash can still turn out to be an arrow function parameter; the enclosing
+// group reports the key if no => follows.
+func (p *Parser) isShorthand(key ast.Expression) bool {
+	if p.grouping == 0 || !p.peekTokenIs(token.COMMA) && !p.peekTokenIs(token.RBRACE) {
+		return false
+	}
+
+	return shorthandName(key) != nil
+}
+
+// shorthandName returns the name of a shorthand key written name or name = value.
+func shorthandName(key ast.Expression) *ast.Identifier {
+	if assign, ok := key.(*ast.AssignExpression); ok && assign.Operator == "=" {
+		key = assign.Target
+	}
+
+	name, _ := key.(*ast.Identifier)
+
+	return name
+}
@@ -266,6 +266,40 @@ func TestIntegerLiteralExpression(t *testing.T) {
 	}
 }
 
+func TestFloatLiteralExpression(t *testing.T) {
+	input := "1.008;"
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("program has not enough statements. got=%d",
+			len(program.Statements))
+	}
+
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.ExpressionStatement. got=%T",
+			program.Statements[0])
+	}
+
+	literal, ok := stmt.Expression.(*ast.FloatLiteral)
+	if !ok {
+		t.Fatalf("exp not *ast.FloatLiteral. got=%T", stmt.Expression)
+	}
+
+	if literal.Value != 1.008 {
+		t.Errorf("literal.Value not %f. got=%f", 1.008, literal.Value)
+	}
+
+	if literal.TokenLiteral() != "1.008" {
+		t.Errorf("literal.TokenLiteral not %s. got=%s", "1.008",
+			literal.TokenLiteral())
+	}
+}
+
 func TestStringLiteralExpression(t *testing.T) {
 	input := `"hello world";`
 
@@ -720,8 +754,8 @@ func TestReactionLiteralParsing(t *testing.T) {
 		t.Fatalf("function literal parameters wrong. want 2, got=%d\n",
 			len(function.Parameters))
 	}
-	testLiteralExpression(t, function.Parameters[0], "x")
-	testLiteralExpression(t, function.Parameters[1], "y")
+	testLiteralExpression(t, function.Parameters[0].Name, "x")
+	testLiteralExpression(t, function.Parameters[1].Name, "y")
 	if len(function.Body.Statements) != 1 {
 		t.Fatalf("function.Body.Statements has not 1 statements. got=%d\n",
 			len(function.Body.Statements))
@@ -762,8 +796,8 @@ func TestReactionStatementParsing(t *testing.T) {
 			len(stmt.Parameters))
 	}
 
-	testLiteralExpression(t, stmt.Parameters[0], "x")
-	testLiteralExpression(t, stmt.Parameters[1], "y")
+	testLiteralExpression(t, stmt.Parameters[0].Name, "x")
+	testLiteralExpression(t, stmt.Parameters[1].Name, "y")
 
 	if len(stmt.Body.Statements) != 1 {
 		t.Fatalf("reaction.Body.Statements has not 1 statements. got=%d\n",
@@ -799,7 +833,7 @@ func TestReactionParameterParsing(t *testing.T) {
 				len(tt.expectedParams), len(function.Parameters))
 		}
 		for i, ident := range tt.expectedParams {
-			testLiteralExpression(t, function.Parameters[i], ident)
+			testLiteralExpression(t, function.Parameters[i].Name, ident)
 		}
 	}
 }
@@ -1136,3 +1170,1202 @@ func testBooleanLiteral(t *testing.T, exp ast.Expression, value bool) bool {
 
 	return true
 }
+
+func TestParserErrorPositions(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedError string
+	}{
+		{"atom = 5;", "1:6: expected next token to be IDENT, got = instead"},
+		{"atom x = 5;\natom y 10;", "2:8: expected next token to be =, got INT instead"},
+		{"\n  + 1", "2:3: no prefix parse function for + found"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+
+		if len(errors) == 0 {
+			t.Errorf("expected parser errors for %q, got none", tt.input)
+			continue
+		}
+
+		if errors[0] != tt.expectedError {
+			t.Errorf("wrong error. expected=%q, got=%q", tt.expectedError, errors[0])
+		}
+	}
+}
+
+func TestNodePositions(t *testing.T) {
+	input := "atom a = 1;\nadd(a, 2 * 3);"
+
+	l := lexer.NewFile("main.atom", input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	call := program.Statements[1].(*ast.ExpressionStatement).Expression.(*ast.CallExpression)
+
+	if got := call.Function.Pos().String(); got != "main.atom:2:1" {
+		t.Errorf("call.Function.Pos() wrong. got=%s", got)
+	}
+
+	if got := call.Arguments[1].Pos().String(); got != "main.atom:2:10" {
+		t.Errorf("call.Arguments[1].Pos() wrong. got=%s", got)
+	}
+}
+
+func TestParsingWithComments(t *testing.T) {
+	input := `// element mass
+atom mass = 1; /* hydrogen */
+mass + /* inline */ 2;`
+
+	l := lexer.New(input)
+	l.SetMode(lexer.ScanComments)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if program.String() != "atom mass = 1;(mass + 2)" {
+		t.Errorf("program.String() wrong. got=%q", program.String())
+	}
+}
+
+func TestInterpolatedStringParsing(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedParts int
+		expected      string
+	}{
+		{`"a ${x} b"`, 3, `"a ${x} b"`},
+		{`"${x}${y + 1}"`, 2, `"${x}${(y + 1)}"`},
+		{`"outer ${"inner ${z}"}"`, 2, `"outer ${"inner ${z}"}"`},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		str, ok := stmt.Expression.(*ast.InterpolatedString)
+		if !ok {
+			t.Fatalf("exp is not ast.InterpolatedString. got=%T", stmt.Expression)
+		}
+
+		if len(str.Parts) != tt.expectedParts {
+			t.Errorf("wrong number of parts. want=%d, got=%d", tt.expectedParts, len(str.Parts))
+		}
+
+		if str.String() != tt.expected {
+			t.Errorf("str.String() wrong. want=%q, got=%q", tt.expected, str.String())
+		}
+	}
+}
+
+func TestNumberLiteralForms(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"0xFF", int64(255)},
+		{"0o755", int64(493)},
+		{"0b1010", int64(10)},
+		{"6_022_140_76", int64(602214076)},
+		{"6.022e23", 6.022e23},
+		{"1E-3", 0.001},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		exp := program.Statements[0].(*ast.ExpressionStatement).Expression
+
+		switch expected := tt.expected.(type) {
+		case int64:
+			lit, ok := exp.(*ast.IntegerLiteral)
+			if !ok {
+				t.Errorf("exp not *ast.IntegerLiteral. got=%T", exp)
+				continue
+			}
+			if lit.Value != expected {
+				t.Errorf("lit.Value not %d. got=%d", expected, lit.Value)
+			}
+		case float64:
+			lit, ok := exp.(*ast.FloatLiteral)
+			if !ok {
+				t.Errorf("exp not *ast.FloatLiteral. got=%T", exp)
+				continue
+			}
+			if lit.Value != expected {
+				t.Errorf("lit.Value not %g. got=%g", expected, lit.Value)
+			}
+		}
+	}
+}
+
+func TestNumberLiteralErrors(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedError string
+	}{
+		{"atom n =\n  99999999999999999999;", "2:3: integer literal 99999999999999999999 overflows int64 (max 9223372036854775807)"},
+		{"1__0", `1:1: could not parse "1__0" as integer`},
+		{"x + 0xFG", `1:5: could not parse "0xFG" as integer`},
+		{"010", "1:1: integer literal 010 has a leading zero (use 0o10 for octal or 10 for decimal)"},
+		{"x + 08", "1:5: integer literal 08 has a leading zero (use 8)"},
+		{"1e400", "1:1: float literal 1e400 is out of range"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+
+		if len(errors) == 0 {
+			t.Errorf("expected parser errors for %q, got none", tt.input)
+			continue
+		}
+
+		if errors[0] != tt.expectedError {
+			t.Errorf("wrong error. expected=%q, got=%q", tt.expectedError, errors[0])
+		}
+	}
+}
+
+func TestLexerDiagnosticsInErrors(t *testing.T) {
+	input := `atom a = 1 # 2;
+atom b 3;
+"open`
+
+	l := lexer.New(input)
+	p := New(l)
+	p.ParseProgram()
+
+	expected := []string{
+		"1:12: unexpected character `#` (comments start with `//`)",
+		"2:8: expected next token to be =, got INT instead",
+		"3:1: unterminated string `\"open` (add a closing `\"`)",
+	}
+
+	errors := p.Errors()
+
+	if len(errors) != len(expected) {
+		t.Fatalf("wrong number of errors. expected=%d, got=%q", len(expected), errors)
+	}
+
+	for i, want := range expected {
+		if errors[i] != want {
+			t.Errorf("error[%d] wrong. expected=%q, got=%q", i, want, errors[i])
+		}
+	}
+}
+
+func TestWhileStatement(t *testing.T) {
+	input := `while (x < y) { x }`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("program.Statements does not contain 1 statements. got=%d",
+			len(program.Statements))
+	}
+
+	stmt, ok := program.Statements[0].(*ast.WhileStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.WhileStatement. got=%T",
+			program.Statements[0])
+	}
+
+	if !testInfixExpression(t, stmt.Condition, "x", "<", "y") {
+		return
+	}
+
+	if len(stmt.Body.Statements) != 1 {
+		t.Errorf("body is not 1 statements. got=%d", len(stmt.Body.Statements))
+	}
+}
+
+func TestForStatement(t *testing.T) {
+	tests := []struct {
+		input            string
+		expectedKey      string
+		expectedValue    string
+		expectedIterable string
+	}{
+		{`for (x in xs) { x }`, "", "x", "xs"},
+		{`for (i, x in range(0, 10)) { x }`, "i", "x", "range(0, 10)"},
+		{`for (k, v in {"a": 1}) { v }`, "k", "v", "{a:1}"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt, ok := program.Statements[0].(*ast.ForStatement)
+		if !ok {
+			t.Fatalf("program.Statements[0] is not ast.ForStatement. got=%T",
+				program.Statements[0])
+		}
+
+		if tt.expectedKey == "" {
+			if stmt.Key != nil {
+				t.Errorf("stmt.Key is not nil. got=%s", stmt.Key)
+			}
+		} else if !testIdentifier(t, stmt.Key, tt.expectedKey) {
+			return
+		}
+
+		if !testIdentifier(t, stmt.Value, tt.expectedValue) {
+			return
+		}
+
+		if stmt.Iterable.String() != tt.expectedIterable {
+			t.Errorf("stmt.Iterable wrong. want=%q, got=%q",
+				tt.expectedIterable, stmt.Iterable.String())
+		}
+	}
+}
+
+func TestLoopParsingErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`for (x xs) { x }`, "1:8: expected next token to be IN, got IDENT instead"},
+		{`while x { x }`, "1:7: expected next token to be (, got IDENT instead"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Fatalf("expected parser errors for %q", tt.input)
+		}
+
+		if errors[0] != tt.expected {
+			t.Errorf("wrong error. want=%q, got=%q", tt.expected, errors[0])
+		}
+	}
+}
+
+func TestLoopControlStatements(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`while (x) { break; }`, "while x break;"},
+		{`for (x in xs) { continue }`, "for (x in xs) continue;"},
+		{`outer: for (x in xs) { for (y in ys) { break outer; } }`,
+			"outer: for (x in xs) for (y in ys) break outer;"},
+		{`outer: while (x) { while (y) { if (y) { continue outer; } } }`,
+			"outer: while x while y ify continue outer;"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if program.String() != tt.expected {
+			t.Errorf("program.String() wrong. want=%q, got=%q", tt.expected, program.String())
+		}
+	}
+}
+
+func TestLoopControlErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`break;`, "1:1: break outside of a loop"},
+		{`if (x) { continue; }`, "1:10: continue outside of a loop"},
+		{`for (x in xs) { reaction() { continue; } }`, "1:30: continue outside of a loop"},
+		{`for (x in xs) { break inner; }`, "1:23: break to unknown loop label inner"},
+		{`a: for (x in xs) { a: while (x) { } }`, "1:20: loop label a is already in use"},
+		{`a: 5`, "1:4: label a must be followed by a loop, got INT instead"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Fatalf("expected parser errors for %q", tt.input)
+		}
+
+		if errors[0] != tt.expected {
+			t.Errorf("wrong error. want=%q, got=%q", tt.expected, errors[0])
+		}
+	}
+}
+
+func TestAssignExpressionParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`x = 5`, "(x = 5)"},
+		{`x = y = 1 + 2`, "(x = (y = (1 + 2)))"},
+		{`x += y * 2`, "(x += (y * 2))"},
+		{`x -= 1; x *= 2; x /= 3; x %= 4`, "(x -= 1)(x *= 2)(x /= 3)(x %= 4)"},
+		{`x = a == b`, "(x = (a == b))"},
+		{`f(x = 2)`, "f((x = 2))"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if program.String() != tt.expected {
+			t.Errorf("program.String() wrong. want=%q, got=%q", tt.expected, program.String())
+		}
+	}
+}
+
+func TestAssignExpressionErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`1 = 2`, "1:1: cannot assign to 1"},
+		{`x + y = 2`, "1:3: cannot assign to (x + y)"},
+		{`if (x) = 3`, "1:8: expected next token to be {, got = instead"},
+		{`(1, 2) = 3`, "1:8: expected next token to be =>, got = instead"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Fatalf("expected parser errors for %q", tt.input)
+		}
+
+		if errors[0] != tt.expected {
+			t.Errorf("wrong error. want=%q, got=%q", tt.expected, errors[0])
+		}
+	}
+}
+
+func TestMoleculeChecks(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`molecule c = 1; c = 2;`, "1:17: cannot assign to molecule c"},
+		{`molecule c = 1; c += 2;`, "1:17: cannot assign to molecule c"},
+		{`molecule c = 1; molecule c = 2;`, "1:26: cannot redeclare molecule c"},
+		{`molecule c = 1; atom c = 2;`, "1:22: cannot redeclare molecule c"},
+		{`molecule c = 1; reaction c() { 1 }`, "1:26: cannot redeclare molecule c"},
+		{`molecule c = 1; reaction f() { c = 2; }`, "1:32: cannot assign to molecule c"},
+		{`molecule c = 1; if (true) { atom c = 2; }`, "1:34: cannot redeclare molecule c"},
+		{`molecule c = 1; for (x in xs) { c = x; }`, "1:33: cannot assign to molecule c"},
+		{`if (c) { molecule m = 1; } else { atom x = 2; } molecule m = 3;`, "1:58: cannot redeclare molecule m"},
+		{`if (c) { atom m = 1; } else { molecule m = 2; } m = 3;`, "1:49: cannot assign to molecule m"},
+		{`molecule c = 1; (c = 2);`, "1:18: cannot assign to molecule c"},
+		{`molecule c = 1; atom f = (x = (c = 2)) => x;`, "1:32: cannot assign to molecule c"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) != 1 {
+			t.Fatalf("expected 1 parser error for %q, got=%v", tt.input, errors)
+		}
+
+		if errors[0] != tt.expected {
+			t.Errorf("wrong error. want=%q, got=%q", tt.expected, errors[0])
+		}
+	}
+}
+
+func TestMoleculeShadowing(t *testing.T) {
+	tests := []string{
+		`molecule c = 1; reaction f() { atom c = 2; c = 3; }`,
+		`molecule c = 1; reaction f(c) { c = 2; }`,
+		`molecule c = 1; for (c in xs) { c = 2; }`,
+		`atom a = 1; atom a = 2; a = 3;`,
+		`atom a = 1; molecule a = 2;`,
+		`if (c) { molecule m = 1; m } else { molecule m = 2; m }`,
+		`molecule c = 1; atom f = (c = 2) => c;`,
+		`molecule c = 1; atom f = ({c = 2}) => c;`,
+	}
+
+	for _, input := range tests {
+		l := lexer.New(input)
+		p := New(l)
+		p.ParseProgram()
+		checkParserErrors(t, p)
+	}
+}
+
+func TestDotAndIndexAssignmentParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`el.mass`, "(el.mass)"},
+		{`table.H.mass * 2`, "(((table.H).mass) * 2)"},
+		{`el.isotopes[0]`, "((el.isotopes)[0])"},
+		{`el.mass = 1`, "((el.mass) = 1)"},
+		{`arr[i + 1] = arr[i]`, "((arr[(i + 1)]) = (arr[i]))"},
+		{`h["k"] += 2`, "((h[k]) += 2)"},
+		{`molecule h = {}; h.k = 1`, "molecule h = {};((h.k) = 1)"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if program.String() != tt.expected {
+			t.Errorf("program.String() wrong. want=%q, got=%q", tt.expected, program.String())
+		}
+	}
+}
+
+func TestDotExpressionIndex(t *testing.T) {
+	l := lexer.New(`el.mass`)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	exp, ok := stmt.Expression.(*ast.IndexExpression)
+	if !ok {
+		t.Fatalf("exp not *ast.IndexExpression. got=%T", stmt.Expression)
+	}
+
+	if !testIdentifier(t, exp.Left, "el") {
+		return
+	}
+
+	str, ok := exp.Index.(*ast.StringLiteral)
+	if !ok || str.Value != "mass" {
+		t.Errorf("exp.Index is not StringLiteral \"mass\". got=%T (%+v)", exp.Index, exp.Index)
+	}
+}
+
+func TestLogicalOperatorPrecedence(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"a || b && c", "(a || (b && c))"},
+		{"a && b || c && d", "((a && b) || (c && d))"},
+		{"a == b && c != d", "((a == b) && (c != d))"},
+		{"a <= b == c >= d", "((a <= b) == (c >= d))"},
+		{"a + b % c * d", "(a + ((b % c) * d))"},
+		{"!a && b", "((!a) && b)"},
+		{"x = a || b", "(x = (a || b))"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if program.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, program.String())
+		}
+	}
+}
+
+func TestNullishAndOptionalChainParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`null`, "null"},
+		{`a ?? b`, "(a ?? b)"},
+		{`a ?? b || c`, "(a ?? (b || c))"},
+		{`x = a ?? b`, "(x = (a ?? b))"},
+		{`a?.b`, "(a?.b)"},
+		{`a?.["k"]`, "(a?.[k])"},
+		{`f?.(1, 2)`, "f?.(1, 2)"},
+		{`a?.b.c[0]`, "(((a?.b).c)[0])"},
+		{`a?.b ?? 0`, "((a?.b) ?? 0)"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if program.String() != tt.expected {
+			t.Errorf("program.String() wrong. want=%q, got=%q", tt.expected, program.String())
+		}
+	}
+}
+
+func TestOptionalChainErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`a?.1`, "1:4: expected [, ( or a name after ?., got INT instead"},
+		{`a?.b = 1`, "1:2: cannot assign to optional chain (a?.b)"},
+		{`a?.b.c = 1`, "1:5: cannot assign to optional chain ((a?.b).c)"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Fatalf("expected parser errors for %q", tt.input)
+		}
+
+		if errors[0] != tt.expected {
+			t.Errorf("wrong error. want=%q, got=%q", tt.expected, errors[0])
+		}
+	}
+}
+
+func TestConditionalAndPipeParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`a ? b : c`, "(a ? b : c)"},
+		{`a > 1 ? b + 1 : c * 2`, "((a > 1) ? (b + 1) : (c * 2))"},
+		{`a ? b : c ? d : e`, "(a ? b : (c ? d : e))"},
+		{`a ? b ? c : d : e`, "(a ? (b ? c : d) : e)"},
+		{`x = a ? b : c`, "(x = (a ? b : c))"},
+		{`a ?? b ? c : d`, "((a ?? b) ? c : d)"},
+		{`{"k": a ? 1 : 2}`, "{k:(a ? 1 : 2)}"},
+		{`data |> normalize`, "normalize(data)"},
+		{`data |> normalize |> rest`, "rest(normalize(data))"},
+		{`data |> push(4) |> len`, "len(push(data, 4))"},
+		{`a + b |> f`, "f((a + b))"},
+		{`x = data |> f`, "(x = f(data))"},
+		{`a ? b : c |> f`, "f((a ? b : c))"},
+		{`data |> reaction(x) { x }`, "reaction(x) x(data)"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if program.String() != tt.expected {
+			t.Errorf("program.String() wrong. want=%q, got=%q", tt.expected, program.String())
+		}
+	}
+}
+
+func TestPipeParsesIntoCallExpression(t *testing.T) {
+	l := lexer.New(`metals |> push("platinum")`)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	call, ok := stmt.Expression.(*ast.CallExpression)
+	if !ok {
+		t.Fatalf("stmt.Expression is not ast.CallExpression. got=%T", stmt.Expression)
+	}
+
+	if !testIdentifier(t, call.Function, "push") {
+		return
+	}
+
+	if len(call.Arguments) != 2 {
+		t.Fatalf("wrong length of arguments. got=%d", len(call.Arguments))
+	}
+
+	testIdentifier(t, call.Arguments[0], "metals")
+
+	if str, ok := call.Arguments[1].(*ast.StringLiteral); !ok || str.Value != "platinum" {
+		t.Errorf("second argument is not \"platinum\". got=%T (%+v)", call.Arguments[1], call.Arguments[1])
+	}
+}
+
+func TestArrowFunctionParsing(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectedParams []string
+		expected       string
+	}{
+		{`x => x * 2`, []string{"x"}, "reaction(x) (x * 2)"},
+		{`(x) => x * 2`, []string{"x"}, "reaction(x) (x * 2)"},
+		{`(x, y) => x + y`, []string{"x", "y"}, "reaction(x, y) (x + y)"},
+		{`() => 42`, []string{}, "reaction() 42"},
+		{`(a, b) => { produce a; }`, []string{"a", "b"}, "reaction(a, b) produce a;"},
+		{`x => y => x + y`, []string{"x"}, "reaction(x) reaction(y) (x + y)"},
+		{`x => ({"k": x})`, []string{"x"}, "reaction(x) {k:x}"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		rl, ok := stmt.Expression.(*ast.ReactionLiteral)
+		if !ok {
+			t.Fatalf("stmt.Expression is not ast.ReactionLiteral. got=%T", stmt.Expression)
+		}
+
+		if len(rl.Parameters) != len(tt.expectedParams) {
+			t.Fatalf("length parameters wrong. want %d, got=%d",
+				len(tt.expectedParams), len(rl.Parameters))
+		}
+
+		for i, ident := range tt.expectedParams {
+			testLiteralExpression(t, rl.Parameters[i].Name, ident)
+		}
+
+		if rl.String() != tt.expected {
+			t.Errorf("rl.String() wrong. want=%q, got=%q", tt.expected, rl.String())
+		}
+	}
+}
+
+func TestArrowFunctionsInContext(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`(x)`, "x"},
+		{`(a + b) * c`, "((a + b) * c)"},
+		{`atom f = x => x + 1;`, "atom f = reaction(x) (x + 1);"},
+		{`map(xs, (x) => x * x)`, "map(xs, reaction(x) (x * x))"},
+		{`xs |> map(x => x + 1)`, "map(xs, reaction(x) (x + 1))"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if program.String() != tt.expected {
+			t.Errorf("program.String() wrong. want=%q, got=%q", tt.expected, program.String())
+		}
+	}
+}
+
+func TestArrowFunctionErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`(1) => 2`, "1:2: invalid arrow function parameter 1"},
+		{`(x, y + 1) => 2`, "1:7: invalid arrow function parameter (y + 1)"},
+		{`(x, y)`, "1:7: expected next token to be =>, got EOF instead"},
+		{`()`, "1:3: expected next token to be =>, got EOF instead"},
+		{`for (x in xs) { atom f = () => { break; } }`, "1:34: break outside of a loop"},
+		{`(;) => 1`, "1:2: no prefix parse function for ; found"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Fatalf("expected parser errors for %q", tt.input)
+		}
+
+		if errors[0] != tt.expected {
+			t.Errorf("wrong error. want=%q, got=%q", tt.expected, errors[0])
+		}
+	}
+}
+
+func TestParameterForms(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`reaction(a, b = 2) { a }`, "reaction(a, b = 2) a"},
+		{`reaction(a, ...rest) { rest }`, "reaction(a, ...rest) rest"},
+		{`reaction(a = 1 + 1, ...rest) { a }`, "reaction(a = (1 + 1), ...rest) a"},
+		{`(a, b = 2) => a + b`, "reaction(a, b = 2) (a + b)"},
+		{`(...xs) => xs`, "reaction(...xs) xs"},
+		{`(a, ...xs) => xs`, "reaction(a, ...xs) xs"},
+		{`f(1, b: 2, c: x + 1)`, "f(1, b: 2, c: (x + 1))"},
+		{`f(a ? b : c)`, "f((a ? b : c))"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if program.String() != tt.expected {
+			t.Errorf("program.String() wrong. want=%q, got=%q", tt.expected, program.String())
+		}
+	}
+}
+
+func TestParameterDetails(t *testing.T) {
+	l := lexer.New(`reaction f(a, b = 2, ...rest) { a }`)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ReactionStatement)
+
+	if len(stmt.Parameters) != 3 {
+		t.Fatalf("wrong number of parameters. got=%d", len(stmt.Parameters))
+	}
+
+	a, b, rest := stmt.Parameters[0], stmt.Parameters[1], stmt.Parameters[2]
+
+	if a.Default != nil || a.Rest {
+		t.Errorf("a should be a plain parameter. got=%s", a)
+	}
+
+	if !testIntegerLiteral(t, b.Default, 2) || b.Rest {
+		t.Errorf("b should have default 2. got=%s", b)
+	}
+
+	if !rest.Rest || rest.Name.Value != "rest" {
+		t.Errorf("rest should be a rest parameter. got=%s", rest)
+	}
+}
+
+func TestParameterErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`reaction(...rest, a) { a }`, "1:10: rest parameter ...rest must be the last parameter"},
+		{`reaction(a, a) { a }`, "1:13: duplicate parameter a"},
+		{`reaction(1) { 1 }`, "1:10: expected parameter name, got INT instead"},
+		{`(a, a) => a`, "1:5: duplicate parameter a"},
+		{`(...xs)`, "1:8: expected next token to be =>, got EOF instead"},
+		{`f(a: 1, 2)`, "1:9: positional argument 2 after named arguments"},
+		{`f(a: 1, a: 2)`, "1:9: duplicate named argument a"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Fatalf("expected parser errors for %q", tt.input)
+		}
+
+		if errors[0] != tt.expected {
+			t.Errorf("wrong error. want=%q, got=%q", tt.expected, errors[0])
+		}
+	}
+}
+
+func TestDestructuringParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"atom [a, b, ...rest] = arr;", "atom [a, b, ...rest] = arr;"},
+		{"molecule {name, symbol: sym} = element;", "molecule {name, symbol: sym} = element;"},
+		{"atom {a = 1, b: [c, d = 2], ...others} = h;", "atom {a = 1, b: [c, d = 2], ...others} = h;"},
+		{"atom [] = x;", "atom [] = x;"},
+		{"reaction f([a, b], {c}) { a }", "reaction f([a, b], {c}) a"},
+		{"([a, b] = [1, 2], {x: y}) => a", "reaction([a, b] = [1, 2], {x: y}) a"},
+		{"([a, [b, c] = [2, 3]]) => a", "reaction([a, [b, c] = [2, 3]]) a"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if program.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, program.String())
+		}
+	}
+}
+
+func TestDestructuringPattern(t *testing.T) {
+	l := lexer.New("atom [x, {y: [z] = w}] = v;")
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.AtomStatement)
+	if stmt.Name != nil {
+		t.Fatalf("stmt.Name is not nil. got=%s", stmt.Name)
+	}
+
+	array, ok := stmt.Pattern.(*ast.ArrayPattern)
+	if !ok {
+		t.Fatalf("stmt.Pattern is not *ast.ArrayPattern. got=%T", stmt.Pattern)
+	}
+
+	if len(array.Elements) != 2 || array.Rest != nil {
+		t.Fatalf("wrong array pattern. got=%s", array)
+	}
+
+	testIdentifier(t, array.Elements[0].Target.(*ast.Identifier), "x")
+
+	hash, ok := array.Elements[1].Target.(*ast.HashPattern)
+	if !ok {
+		t.Fatalf("second element is not *ast.HashPattern. got=%T", array.Elements[1].Target)
+	}
+
+	entry := hash.Entries[0]
+	if entry.Key.Value != "y" {
+		t.Errorf("entry.Key is not y. got=%s", entry.Key.Value)
+	}
+
+	if _, ok := entry.Target.(*ast.ArrayPattern); !ok {
+		t.Errorf("entry.Target is not *ast.ArrayPattern. got=%T", entry.Target)
+	}
+
+	testIdentifier(t, entry.Default, "w")
+}
+
+func TestDestructuringErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"atom [a, a] = x;", "1:10: duplicate binding a"},
+		{"molecule {a, b: a} = x;", "1:17: duplicate binding a"},
+		{"atom {1: a} = x;", "1:7: expected a key name in hash pattern, got INT instead"},
+		{"atom [...r, a] = x;", "1:11: expected next token to be ], got , instead"},
+		{"molecule [a] = x; a = 2;", "1:19: cannot assign to molecule a"},
+		{"reaction f([a], a) { a }", "1:17: duplicate parameter a"},
+		{"[a] = 1", "1:1: cannot assign to [a]"},
+		{"([a] = 1)", "1:2: cannot assign to [a]"},
+		{"(x = f([a] = 1)) => x", "1:8: cannot assign to [a]"},
+		{"({name})", "1:3: expected : and a value after hash key name"},
+		{"(x = {a}) => x", "1:7: expected : and a value after hash key a"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Fatalf("expected parser errors for %q", tt.input)
+		}
+
+		if errors[0] != tt.expected {
+			t.Errorf("wrong error. want=%q, got=%q", tt.expected, errors[0])
+		}
+	}
+}
+
+func TestSpreadParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"[...a, ...b]", "[...a, ...b]"},
+		{"[1, ...xs, 2]", "[1, ...xs, 2]"},
+		{"{...defaults, ...overrides}", "{...defaults, ...overrides}"},
+		{`{"a": 1, ...rest, "b": 2}`, "{a:1, ...rest, b:2}"},
+		{"f(...args)", "f(...args)"},
+		{"f(a, ...[1, 2] |> g)", "f(a, ...g([1, 2]))"},
+		{"f(...xs, b: 1)", "f(...xs, b: 1)"},
+		{"([a, ...rest]) => rest", "reaction([a, ...rest]) rest"},
+		{"({x: y, ...others}) => y", "reaction({x: y, ...others}) y"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if program.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, program.String())
+		}
+	}
+}
+
+func TestSpreadErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"[...]", "1:5: no prefix parse function for ] found"},
+		{"f(a: 1, ...xs)", "1:9: positional argument ...xs after named arguments"},
+		{"([...a, b]) => a", "1:2: invalid arrow function parameter [...a, b]"},
+		{"({...[a]}) => a", "1:2: invalid arrow function parameter {...[a]}"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Fatalf("expected parser errors for %q", tt.input)
+		}
+
+		if errors[0] != tt.expected {
+			t.Errorf("wrong error. want=%q, got=%q", tt.expected, errors[0])
+		}
+	}
+}
+
+func TestMatchExpressionParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`match x { 1 => "one", _ => "many" }`, `match x { 1 => one, _ => many }`},
+		{`match x { -1 => a 2.5 => b null => c }`, `match x { (-1) => a, 2.5 => b, null => c }`},
+		{`match el { {group: 18} => 1, {name, ...rest} if len(rest) > 0 => 2, n => 3 }`,
+			`match el { {group: 18} => 1, {name, ...rest} if (len(rest) > 0) => 2, n => 3 }`},
+		{`match xs { [] => 0, [x, ...others] => { x + 1 } }`, `match xs { [] => 0, [x, ...others] => (x + 1) }`},
+		{`match b { true => 1, false => 0 }`, `match b { true => 1, false => 0 }`},
+		{`match x { n if ok => n }`, `match x { n if ok => n }`},
+		{`match x { n if any(xs, y => y > n) => n }`, `match x { n if any(xs, reaction(y) (y > n)) => n }`},
+		{`match x { y if ((z) => z)(y) => y }`, `match x { y if reaction(z) z(y) => y }`},
+		{`match x { y if (y) => y }`, `match x { y if y => y }`},
+		{`match x {}`, `match x {  }`},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if program.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, program.String())
+		}
+	}
+}
+
+func TestMatchArms(t *testing.T) {
+	l := lexer.New(`match x { 1 if y => a, [_, z] => z, _ => null }`)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+
+	exp, ok := stmt.Expression.(*ast.MatchExpression)
+	if !ok {
+		t.Fatalf("stmt.Expression is not *ast.MatchExpression. got=%T", stmt.Expression)
+	}
+
+	testIdentifier(t, exp.Subject, "x")
+
+	if len(exp.Arms) != 3 {
+		t.Fatalf("wrong number of arms. want=3, got=%d", len(exp.Arms))
+	}
+
+	literal, ok := exp.Arms[0].Pattern.(*ast.LiteralPattern)
+	if !ok {
+		t.Fatalf("arm 0 is not *ast.LiteralPattern. got=%T", exp.Arms[0].Pattern)
+	}
+
+	testIntegerLiteral(t, literal.Value, 1)
+	testIdentifier(t, exp.Arms[0].Guard, "y")
+
+	array, ok := exp.Arms[1].Pattern.(*ast.ArrayPattern)
+	if !ok {
+		t.Fatalf("arm 1 is not *ast.ArrayPattern. got=%T", exp.Arms[1].Pattern)
+	}
+
+	if _, ok := array.Elements[0].Target.(*ast.WildcardPattern); !ok {
+		t.Errorf("first element is not *ast.WildcardPattern. got=%T", array.Elements[0].Target)
+	}
+
+	if exp.Arms[1].Guard != nil {
+		t.Errorf("arm 1 has a guard: %s", exp.Arms[1].Guard)
+	}
+
+	if _, ok := exp.Arms[2].Pattern.(*ast.WildcardPattern); !ok {
+		t.Errorf("arm 2 is not *ast.WildcardPattern. got=%T", exp.Arms[2].Pattern)
+	}
+}
+
+func TestMatchErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`match x { 1 + 2 => a }`, "1:13: expected next token to be =>, got + instead"},
+		{`match x { -y => a }`, "1:12: expected a number after - in match pattern, got IDENT instead"},
+		{`match x { "${y}" => a }`, `1:11: expected a literal in match pattern, got "${y}"`},
+		{`match x { (a) => a }`, "1:11: expected a literal, name, _, [ or { in match pattern, got ( instead"},
+		{`match x { [a, a] => a }`, "1:15: duplicate binding a"},
+		{`match x { 1 => a`, "1:17: expected next token to be }, got EOF instead"},
+		{`atom _ = 1; atom [1] = x;`, "1:19: expected a name, [ or { in pattern, got INT instead"},
+		{`molecule m = 1; match x { n => m = 2 }`, "1:32: cannot assign to molecule m"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Fatalf("expected parser errors for %q", tt.input)
+		}
+
+		if errors[0] != tt.expected {
+			t.Errorf("wrong error. want=%q, got=%q", tt.expected, errors[0])
+		}
+	}
+}
+
+func TestMatchExhaustivenessWarnings(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{`match x { 1 => a, 2 => b }`, []string{"1:1: warning: match is not exhaustive: add a _ arm for values other than 1, 2"}},
+		{`atom y = match x { "a" => 1, n if n > 0 => 2 };`, []string{`1:10: warning: match is not exhaustive: add a _ arm for values other than a`}},
+		{`match x { true if y => 1, false => 0 }`, []string{"1:1: warning: match is not exhaustive: add a _ arm for values other than true, false"}},
+		{`match x { 1 => a, _ => b }`, nil},
+		{`match x { 1 => a, n => b }`, nil},
+		{`match x { true => 1, false => 0 }`, nil},
+		{`match x { [a] => a, {b} => b }`, nil},
+		{`match x { 1 => a, [b] => b }`, nil},
+		{`match x {}`, nil},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+		checkParserErrors(t, p)
+
+		warnings := p.Warnings()
+		if len(warnings) != len(tt.expected) {
+			t.Errorf("wrong warnings for %q. want=%q, got=%q", tt.input, tt.expected, warnings)
+			continue
+		}
+
+		for i, warning := range warnings {
+			if warning != tt.expected[i] {
+				t.Errorf("wrong warning. want=%q, got=%q", tt.expected[i], warning)
+			}
+		}
+	}
+}
+
+func TestThrowAndTryParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`throw "boom";`, `throw boom;`},
+		{`throw error("x", "LookupError")`, `throw error(x, LookupError);`},
+		{`try { f() } catch (e) { e.message }`, `try f() catch (e) (e.message)`},
+		{`try { f() } catch { 0 }`, `try f() catch 0`},
+		{`try { f() } finally { g() }`, `try f() finally g()`},
+		{`try { f() } catch (e) { 1 } finally { g() }`, `try f() catch (e) 1 finally g()`},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if program.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, program.String())
+		}
+	}
+}
+
+func TestTryStatement(t *testing.T) {
+	l := lexer.New(`try { risky(); } catch (err) { handle(err); }`)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt, ok := program.Statements[0].(*ast.TryStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not *ast.TryStatement. got=%T", program.Statements[0])
+	}
+
+	if len(stmt.Block.Statements) != 1 {
+		t.Errorf("try block has wrong number of statements. got=%d", len(stmt.Block.Statements))
+	}
+
+	testIdentifier(t, stmt.Param, "err")
+
+	if stmt.Catch == nil || len(stmt.Catch.Statements) != 1 {
+		t.Errorf("catch block is wrong. got=%v", stmt.Catch)
+	}
+
+	if stmt.Finally != nil {
+		t.Errorf("stmt.Finally is not nil. got=%s", stmt.Finally)
+	}
+}
+
+func TestTryErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`try { f() }`, "1:12: expected catch or finally after try block, got EOF instead"},
+		{`try f()`, "1:5: expected next token to be {, got IDENT instead"},
+		{`try { f() } catch (1) { 0 }`, "1:20: expected next token to be IDENT, got INT instead"},
+		{`try { f() } catch (e { 0 }`, "1:22: expected next token to be ), got { instead"},
+		{`try { f() } finally 1`, "1:21: expected next token to be {, got INT instead"},
+		{`molecule e = 1; try { f() } catch (e) { e = 2 } e = 3`, "1:49: cannot assign to molecule e"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Fatalf("expected parser errors for %q", tt.input)
+		}
+
+		if errors[0] != tt.expected {
+			t.Errorf("wrong error. want=%q, got=%q", tt.expected, errors[0])
+		}
+	}
+}
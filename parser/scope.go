@@ -0,0 +1,81 @@
+package parser
+
+import "atom_script/ast"
+
+// scope mirrors the environments the evaluator will create, so that writes to
+// molecules can be reported while parsing. A new scope starts at every reaction
+// body and every pass of a loop body; if blocks share their enclosing scope's
+// environment, but each branch gets a scope of its own since only one of them runs.
+type scope struct {
+	molecules map[string]bool // every name bound here, true for molecules
+	outer     *scope
+	branch    bool // a branch of an if, whose names end up in outer
+}
+
+func newScope(outer *scope) *scope {
+	return &scope{molecules: make(map[string]bool), outer: outer}
+}
+
+// lookup reports whether name is bound in s or an outer scope, and whether the nearest binding is a molecule.
+func (s *scope) lookup(name string) (found, molecule bool) {
+	for sc := s; sc != nil; sc = sc.outer {
+		if molecule, ok := sc.molecules[name]; ok {
+			return true, molecule
+		}
+	}
+
+	return false, false
+}
+
+// declare binds name in the current scope, reporting an error when it is already a molecule
+// in the environment the binding goes to.
+func (p *Parser) declare(name *ast.Identifier, molecule bool) {
+	for sc := p.scope; sc != nil; sc = sc.outer {
+		if sc.molecules[name.Value] {
+			p.errorAt(name.Pos(), "cannot redeclare molecule %s", name.Value)
+			return
+		}
+
+		if !sc.branch {
+			break
+		}
+	}
+
+	p.scope.molecules[name.Value] = molecule
+}
+
+// enterScope opens a scope holding the given atom bindings and returns the function that closes it.
+func (p *Parser) enterScope(names ...*ast.Identifier) func() {
+	p.scope = newScope(p.scope)
+
+	for _, name := range names {
+		if name != nil {
+			p.scope.molecules[name.Value] = false
+		}
+	}
+
+	return func() { p.scope = p.scope.outer }
+}
+
+// parseBranch parses a branch of an if in a scope of its own and returns the names it bound.
+func (p *Parser) parseBranch() (*ast.BlockStatement, map[string]bool) {
+	p.scope = newScope(p.scope)
+	p.scope.branch = true
+
+	block := p.parseBlockStatement()
+
+	branch := p.scope
+	p.scope = branch.outer
+
+	return block, branch.molecules
+}
+
+// mergeBranches moves the names bound in the branches of an if to the enclosing scope,
+// a molecule in either branch counting as a molecule there.
+func (p *Parser) mergeBranches(branches ...map[string]bool) {
+	for _, names := range branches {
+		for name, molecule := range names {
+			p.scope.molecules[name] = p.scope.molecules[name] || molecule
+		}
+	}
+}
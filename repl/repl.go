@@ -7,10 +7,18 @@ import (
 	"atom_script/parser"
 	"bufio"
 	"fmt"
+	"io"
 	"os"
 )
 
 func Start() {
+	// When the input is piped in rather than typed, there is no one to prompt,
+	// so lex the whole stream as one program.
+	if stat, err := os.Stdin.Stat(); err == nil && stat.Mode()&os.ModeCharDevice == 0 {
+		Run(os.Stdin, os.Stdout)
+		return
+	}
+
 	scanner := bufio.NewScanner(os.Stdin)
 
 	fmt.Println("Welcome to Atom Script! Feel free to type in commands")
@@ -31,14 +39,53 @@ func Start() {
 			}
 		}
 
+		for _, warning := range p.Warnings() {
+			fmt.Println(warning)
+		}
+
 		for _, stmt := range program.Statements {
 			evaluated := evaluator.Eval(stmt, env)
 
 			if evaluated != nil {
-				fmt.Println(evaluated.Inspect())
+				fmt.Println(object.Report(evaluated))
 			}
 
 			fmt.Print(">> ")
 		}
 	}
 }
+
+// Run evaluates the program read from in, printing the value of every statement to out.
+// The lexer reads straight from in, without copying the source into one string first.
+func Run(in io.Reader, out io.Writer) {
+	l := lexer.NewReader("<stdin>", in)
+
+	p := parser.New(l)
+
+	program := p.ParseProgram()
+
+	if l.Err() != nil {
+		fmt.Fprintln(out, "Error reading input:", l.Err())
+		return
+	}
+
+	if len(p.Errors()) != 0 {
+		for _, err := range p.Errors() {
+			fmt.Fprintln(out, err)
+		}
+	}
+
+	for _, warning := range p.Warnings() {
+		fmt.Fprintln(out, warning)
+	}
+
+	env := object.NewEnvironment()
+
+	for _, stmt := range program.Statements {
+		evaluated := evaluator.Eval(stmt, env)
+
+		if evaluated != nil {
+			fmt.Fprintln(out, object.Report(evaluated))
+		}
+	}
+}
@@ -1,21 +1,61 @@
 package token
 
+import "fmt"
+
 type TokenType string
 
 type Token struct {
 	Type    TokenType
 	Literal string
+	Start   Position // position of the first character of the token
+	End     Position // position just after the last character of the token
+}
+
+// Position is a location in the source code.
+// Line and Column start at 1, Offset is the byte offset from the start of the input.
+// The zero value is an unknown position.
+type Position struct {
+	File   string
+	Line   int
+	Column int
+	Offset int
+}
+
+// IsValid reports whether the position points somewhere in the source.
+func (p Position) IsValid() bool {
+	return p.Line > 0
+}
+
+// String formats the position as file:line:col, leaving out the file name when it is not known.
+func (p Position) String() string {
+	if !p.IsValid() {
+		return "-"
+	}
+
+	if p.File == "" {
+		return fmt.Sprintf("%d:%d", p.Line, p.Column)
+	}
+
+	return fmt.Sprintf("%s:%d:%d", p.File, p.Line, p.Column)
 }
 
 const (
 	ILLEGAL = "ILLEGAL"
 	EOF     = "EOF"
+	COMMENT = "COMMENT" // only produced when the lexer is asked to keep comments
 
 	// Identifiers + literals
 	IDENT  = "IDENT" // add, foobar, x, y, ...
 	INT    = "INT"   // 1343456
+	FLOAT  = "FLOAT" // 1.008
 	STRING = "STRING"
 
+	// An interpolated string "a ${x} b ${y} c" is split into STRING_HEAD("a "), the tokens of x,
+	// STRING_MIDDLE(" b "), the tokens of y and STRING_TAIL(" c").
+	STRING_HEAD   = "STRING_HEAD"
+	STRING_MIDDLE = "STRING_MIDDLE"
+	STRING_TAIL   = "STRING_TAIL"
+
 	// Operators
 	ASSIGN   = "="
 	PLUS     = "+"
@@ -27,6 +67,23 @@ const (
 	GT       = ">"
 	EQ       = "=="
 	NOT_EQ   = "!="
+	LT_EQ    = "<="
+	GT_EQ    = ">="
+	PERCENT  = "%"
+	AND      = "&&"
+	OR       = "||"
+	NULLISH  = "??"
+	OPTIONAL = "?."
+	QUESTION = "?"
+	PIPE     = "|>"
+	ARROW    = "=>"
+
+	// Assignment operators that combine with arithmetic: x += y is x = x + y
+	PLUS_ASSIGN     = "+="
+	MINUS_ASSIGN    = "-="
+	ASTERISK_ASSIGN = "*="
+	SLASH_ASSIGN    = "/="
+	PERCENT_ASSIGN  = "%="
 
 	LBRACKET = "["
 	RBRACKET = "]"
@@ -39,6 +96,8 @@ const (
 	LBRACE    = "{"
 	RBRACE    = "}"
 	COLON     = ":"
+	DOT       = "."
+	ELLIPSIS  = "..."
 
 	// Keywords
 	ATOM     = "ATOM"
@@ -49,6 +108,17 @@ const (
 	IF       = "IF"
 	ELSE     = "ELSE"
 	PRODUCE  = "PRODUCE"
+	WHILE    = "WHILE"
+	FOR      = "FOR"
+	IN       = "IN"
+	BREAK    = "BREAK"
+	CONTINUE = "CONTINUE"
+	NULL     = "NULL"
+	MATCH    = "MATCH"
+	THROW    = "THROW"
+	TRY      = "TRY"
+	CATCH    = "CATCH"
+	FINALLY  = "FINALLY"
 )
 
 // Instead of let, const and fn we are using ATOM, MOLECULE and REACTION. We are also using PRODUCE instead of return.
@@ -62,6 +132,17 @@ var keywords = map[string]TokenType{
 	"if":       IF,
 	"else":     ELSE,
 	"produce":  PRODUCE,
+	"while":    WHILE,
+	"for":      FOR,
+	"in":       IN,
+	"break":    BREAK,
+	"continue": CONTINUE,
+	"null":     NULL,
+	"match":    MATCH,
+	"throw":    THROW,
+	"try":      TRY,
+	"catch":    CATCH,
+	"finally":  FINALLY,
 }
 
 // LookupIdent checks the keywords table to see whether the given identifier is in fact a keyword.